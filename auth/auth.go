@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// ErrUnauthenticated is an error used to represent a request without (valid) credentials
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal is a type that represents the authenticated identity of a request
+type Principal struct {
+	// Name identifies the principal e.g. a token's principal or a JWT's subject
+	Name string
+	// Groups are the principal's groups
+	Groups []string
+	// Method is the authentication method (token, jwt)
+	Method string
+}
+
+// principalKey is a type that represents the context key of the principal
+type principalKey struct{}
+
+// WithPrincipal is a function that returns a copy of ctx with the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom is a function that returns the principal of ctx (if any)
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// Authenticator is a type that represents a method of authenticating bearer tokens
+type Authenticator interface {
+	// Authenticate returns the principal of the token or an error wrapping ErrUnauthenticated
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// New is a function that creates an Authenticator from the configuration
+// If no method is configured, it returns nil (requests aren't authenticated)
+// If multiple methods are configured, tokens are authenticated by any of them
+func New(c config.Auth, logger *slog.Logger) (Authenticator, error) {
+	authenticators := chain{}
+
+	if c.TokensFile != "" {
+		t := &tokens{
+			path: c.TokensFile,
+		}
+		// Fail fast if the file can't be read
+		if _, err := t.read(); err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, t)
+		logger.Info("Authenticating static bearer tokens", "tokens_file", c.TokensFile)
+	}
+
+	if c.OIDC.Enabled() {
+		j, err := newJWT(c.OIDC, http.DefaultClient)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, j)
+		logger.Info("Authenticating JWTs", "issuer", c.OIDC.Issuer, "audience", c.OIDC.Audience, "jwks_url", j.keys.url)
+	}
+
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	default:
+		return authenticators, nil
+	}
+}
+
+// chain is a type that represents an Authenticator that authenticates tokens using any of its Authenticators
+type chain []Authenticator
+
+// Authenticate is a method that implements Authenticator
+// It returns the first principal or, if none, the last error
+func (c chain) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	err := ErrUnauthenticated
+	for _, a := range c {
+		var principal *Principal
+		principal, err = a.Authenticate(ctx, token)
+		if err == nil {
+			return principal, nil
+		}
+	}
+	return nil, err
+}
+
+// bearer is a function that returns the bearer token of the request's Authorization header
+func bearer(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Middleware is a function that authenticates requests to the handler
+// Unauthenticated requests are rejected (401); authenticated requests' contexts include the principal (see PrincipalFrom)
+// If authenticator is nil, requests aren't authenticated
+func Middleware(authenticator Authenticator, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if authenticator == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := logger.With("function", "middleware")
+
+			token, ok := bearer(r)
+			if !ok {
+				logger.Info("Unauthenticated request", "remote_addr", r.RemoteAddr, "err", "no bearer token")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="prometheus-mcp-server"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), token)
+			if err != nil {
+				logger.Info("Unauthenticated request", "remote_addr", r.RemoteAddr, "err", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="prometheus-mcp-server", error="invalid_token"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			logger.Debug("Authenticated request", "principal", principal.Name, "method", principal.Method)
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// TestMiddleware tests that requests are authenticated using static tokens or JWTs and that the principal is added to the context
+func TestMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	i := newIssuer(t)
+
+	path := filepath.Join(t.TempDir(), "tokens.yaml")
+	write(t, path, `tokens:
+- principal: ci
+  token: s3cr3t
+`, time.Now())
+
+	a, err := New(config.Auth{
+		TokensFile: path,
+		OIDC: config.OIDC{
+			Issuer:         i.server.URL,
+			Audience:       "prometheus-mcp-server",
+			PrincipalClaim: "sub",
+		},
+	}, logger)
+	if err != nil {
+		t.Fatalf("unable to create authenticator: %+v", err)
+	}
+
+	handler := Middleware(a, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFrom(r.Context())
+		if !ok {
+			t.Errorf("expected principal")
+			return
+		}
+		w.Write([]byte(principal.Name))
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+		principal     string
+	}{
+		{name: "token", authorization: "Bearer s3cr3t", want: http.StatusOK, principal: "ci"},
+		{name: "JWT", authorization: "bearer " + i.sign("ec", i.claims("alice")), want: http.StatusOK, principal: "alice"},
+		{name: "unknown token", authorization: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "basic auth", authorization: "Basic Y2k6czNjcjN0", want: http.StatusUnauthorized},
+		{name: "none", want: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if test.authorization != "" {
+				rqst.Header.Set("Authorization", test.authorization)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, rqst)
+
+			if resp.Code != test.want {
+				t.Fatalf("got: %d; want: %d", resp.Code, test.want)
+			}
+			if test.want == http.StatusUnauthorized {
+				if resp.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("expected WWW-Authenticate header")
+				}
+				return
+			}
+			if got := resp.Body.String(); got != test.principal {
+				t.Errorf("got: %q; want: %q", got, test.principal)
+			}
+		})
+	}
+}
+
+// TestNewDisabled tests that requests aren't authenticated if no method is configured
+func TestNewDisabled(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	a, err := New(config.Auth{}, logger)
+	if err != nil {
+		t.Fatalf("unable to create authenticator: %+v", err)
+	}
+	if a != nil {
+		t.Fatalf("got: %+v; want: nil", a)
+	}
+
+	handler := Middleware(a, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if resp.Code != http.StatusOK {
+		t.Errorf("got: %d; want: %d", resp.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,450 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+const (
+	// leeway is the tolerance of clock skew when validating JWTs' times
+	leeway = time.Minute
+	// maxAge is the duration after which the JWKS is refetched so that rotated keys are used
+	maxAge = time.Hour
+	// minRefresh is the minimum duration between refetches of the JWKS for tokens signed by unknown keys
+	minRefresh = time.Minute
+)
+
+// algorithm is a type that represents a JWS signature algorithm
+type algorithm struct {
+	hash crypto.Hash
+	// verify checks the signature of the digest using the key
+	verify func(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error
+}
+
+// algorithms are the supported (asymmetric) JWS signature algorithms
+// Symmetric algorithms (HS*) and "none" are unsupported since these can't be verified using a JWKS
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256, verify: verifyRSA},
+	"RS384": {hash: crypto.SHA384, verify: verifyRSA},
+	"RS512": {hash: crypto.SHA512, verify: verifyRSA},
+	"PS256": {hash: crypto.SHA256, verify: verifyPSS},
+	"PS384": {hash: crypto.SHA384, verify: verifyPSS},
+	"PS512": {hash: crypto.SHA512, verify: verifyPSS},
+	"ES256": {hash: crypto.SHA256, verify: verifyECDSA},
+	"ES384": {hash: crypto.SHA384, verify: verifyECDSA},
+	"ES512": {hash: crypto.SHA512, verify: verifyECDSA},
+}
+
+// verifyRSA is a function that verifies RSASSA-PKCS1-v1_5 signatures
+func verifyRSA(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	k, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("expected RSA key, got %T", key)
+	}
+	return rsa.VerifyPKCS1v15(k, hash, digest, signature)
+}
+
+// verifyPSS is a function that verifies RSASSA-PSS signatures
+func verifyPSS(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	k, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("expected RSA key, got %T", key)
+	}
+	return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+}
+
+// verifyECDSA is a function that verifies ECDSA signatures
+// JWS signatures are the concatenation of (fixed size) R and S
+func verifyECDSA(key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	k, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("expected ECDSA key, got %T", key)
+	}
+	size := (k.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return fmt.Errorf("expected %d byte signature, got %d", 2*size, len(signature))
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(k, digest, r, s) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// header is a type that represents a JWT's (JOSE) header
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwt is a type that represents an Authenticator of OAuth2/OIDC JWTs
+type jwt struct {
+	issuer         string
+	audience       string
+	principalClaim string
+	groupsClaim    string
+	keys           *jwks
+	now            func() time.Time
+}
+
+// newJWT is a function that creates a JWT Authenticator
+// If the JWKS URL isn't configured, it is discovered from the issuer's OpenID configuration
+func newJWT(c config.OIDC, client *http.Client) (*jwt, error) {
+	url := c.JWKSURL
+	if url == "" {
+		var err error
+		url, err = discover(c.Issuer, client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groupsClaim := c.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	j := &jwt{
+		issuer:         c.Issuer,
+		audience:       c.Audience,
+		principalClaim: c.PrincipalClaim,
+		groupsClaim:    groupsClaim,
+		keys: &jwks{
+			url:    url,
+			client: client,
+		},
+		now: time.Now,
+	}
+	// Fail fast if the JWKS can't be fetched
+	if err := j.keys.fetch(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// discover is a function that returns the JWKS URL of the issuer's OpenID configuration
+func discover(issuer string, client *http.Client) (string, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch OpenID configuration %q: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to fetch OpenID configuration %q: %s", url, resp.Status)
+	}
+
+	configuration := struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&configuration); err != nil {
+		return "", fmt.Errorf("unable to parse OpenID configuration %q: %w", url, err)
+	}
+	if configuration.Issuer != issuer {
+		return "", fmt.Errorf("OpenID configuration %q issuer %q doesn't match %q", url, configuration.Issuer, issuer)
+	}
+	if configuration.JWKSURI == "" {
+		return "", fmt.Errorf("OpenID configuration %q has no jwks_uri", url)
+	}
+
+	return configuration.JWKSURI, nil
+}
+
+// decode is a function that decodes a base64url (unpadded) segment of a JWT into v
+func decode(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Authenticate is a method that implements Authenticator
+// It verifies the JWT's signature using the JWKS and validates its issuer, audience and times
+func (j *jwt) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrUnauthenticated)
+	}
+
+	h := header{}
+	if err := decode(segments[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT header: %w", ErrUnauthenticated, err)
+	}
+	alg, ok := algorithms[h.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported JWT algorithm %q", ErrUnauthenticated, h.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT signature: %w", ErrUnauthenticated, err)
+	}
+
+	hash := alg.hash.New()
+	hash.Write([]byte(segments[0] + "." + segments[1]))
+	digest := hash.Sum(nil)
+
+	keys, err := j.keys.get(ctx, h.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	verified := slices.ContainsFunc(keys, func(key crypto.PublicKey) bool {
+		return alg.verify(key, alg.hash, digest, signature) == nil
+	})
+	if !verified {
+		return nil, fmt.Errorf("%w: invalid JWT signature", ErrUnauthenticated)
+	}
+
+	claims := map[string]any{}
+	if err := decode(segments[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed JWT claims: %w", ErrUnauthenticated, err)
+	}
+	if err := j.validate(claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+
+	name, _ := claims[j.principalClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: JWT has no %q claim", ErrUnauthenticated, j.principalClaim)
+	}
+
+	return &Principal{
+		Name:   name,
+		Groups: strs(claims[j.groupsClaim]),
+		Method: "jwt",
+	}, nil
+}
+
+// validate is a method that validates the JWT's issuer, audience and times
+func (j *jwt) validate(claims map[string]any) error {
+	if j.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != j.issuer {
+			return fmt.Errorf("JWT issuer %q isn't %q", iss, j.issuer)
+		}
+	}
+
+	if aud := strs(claims["aud"]); !slices.Contains(aud, j.audience) {
+		return fmt.Errorf("JWT audience %+q doesn't include %q", aud, j.audience)
+	}
+
+	now := j.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("JWT has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return fmt.Errorf("JWT expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("JWT isn't yet valid")
+	}
+
+	return nil
+}
+
+// strs is a function that converts a claim that is either a string or a list of strings into a list of strings
+// Space-separated strings (e.g. "scope") are split
+func strs(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		ss := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	default:
+		return nil
+	}
+}
+
+// jwks is a type that represents a (cached) JSON Web Key Set
+// The JWKS is refetched when it is older than maxAge or when a JWT is signed by an unknown key
+type jwks struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// get is a method that returns the key identified by kid or, if kid is empty, every key
+func (k *jwks) get(ctx context.Context, kid string) ([]crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	lookup := func() []crypto.PublicKey {
+		if kid == "" {
+			keys := make([]crypto.PublicKey, 0, len(k.keys))
+			for _, key := range k.keys {
+				keys = append(keys, key)
+			}
+			return keys
+		}
+		if key, ok := k.keys[kid]; ok {
+			return []crypto.PublicKey{key}
+		}
+		return nil
+	}
+
+	keys := lookup()
+	age := time.Since(k.fetched)
+	if age > maxAge || (len(keys) == 0 && age > minRefresh) {
+		if err := k.fetchLocked(ctx); err != nil {
+			return nil, err
+		}
+		keys = lookup()
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown JWT key %q", kid)
+	}
+
+	return keys, nil
+}
+
+// fetch is a method that fetches the JWKS
+func (k *jwks) fetch(ctx context.Context) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.fetchLocked(ctx)
+}
+
+// fetchLocked is a method that fetches the JWKS; the caller must hold the lock
+func (k *jwks) fetchLocked(ctx context.Context) error {
+	rqst, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("unable to create JWKS request %q: %w", k.url, err)
+	}
+	resp, err := k.client.Do(rqst)
+	if err != nil {
+		return fmt.Errorf("unable to fetch JWKS %q: %w", k.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch JWKS %q: %s", k.url, resp.Status)
+	}
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("unable to parse JWKS %q: %w", k.url, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i, key := range set.Keys {
+		// Keys that aren't used for signatures (e.g. encryption) are ignored
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		pub, err := key.publicKey()
+		if err != nil {
+			// Keys of unsupported types are ignored
+			continue
+		}
+		kid := key.Kid
+		if kid == "" {
+			kid = fmt.Sprintf("#%d", i)
+		}
+		keys[kid] = pub
+	}
+
+	k.keys = keys
+	k.fetched = time.Now()
+
+	return nil
+}
+
+// jwk is a type that represents a JSON Web Key (RSA or EC public key)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// curves are the supported EC curves
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// publicKey is a method that returns the JWK's public key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	integer := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := integer(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := integer(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		// The (uncompressed) point is validated when it is parsed
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("invalid EC key")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, slices.Concat([]byte{4}, x, y))
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
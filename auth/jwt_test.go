@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// issuer is a type that represents a (mock) OIDC issuer that signs JWTs and publishes its JWKS
+type issuer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]crypto.Signer
+}
+
+// newIssuer is a function that creates an issuer with an RSA key ("rsa") and an ECDSA key ("ec")
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate RSA key: %+v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ECDSA key: %+v", err)
+	}
+
+	i := &issuer{
+		t: t,
+		keys: map[string]crypto.Signer{
+			"rsa": rsaKey,
+			"ec":  ecKey,
+		},
+	}
+
+	mux := http.NewServeMux()
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   i.server.URL,
+			"jwks_uri": i.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(i.jwks())
+	})
+
+	return i
+}
+
+// rotate is a method that adds an RSA key
+func (i *issuer) rotate(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		i.t.Fatalf("unable to generate RSA key: %+v", err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[kid] = key
+}
+
+// jwks is a method that returns the issuer's JWKS
+func (i *issuer) jwks() map[string]any {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	keys := []map[string]string{}
+	for kid, key := range i.keys {
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   encode(key.N.Bytes()),
+				"e":   encode(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case *ecdsa.PrivateKey:
+			b, err := key.PublicKey.Bytes()
+			if err != nil {
+				i.t.Fatalf("unable to encode ECDSA key: %+v", err)
+			}
+			keys = append(keys, map[string]string{
+				"kty": "EC",
+				"kid": kid,
+				"crv": "P-256",
+				"x":   encode(b[1:33]),
+				"y":   encode(b[33:]),
+			})
+		}
+	}
+	return map[string]any{"keys": keys}
+}
+
+// sign is a method that returns a JWT of the claims signed by the key
+func (i *issuer) sign(kid string, claims map[string]any) string {
+	i.t.Helper()
+
+	i.mu.Lock()
+	key := i.keys[kid]
+	i.mu.Unlock()
+
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+
+	segment := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			i.t.Fatalf("unable to marshal JWT: %+v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := segment(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + segment(claims)
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		b, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+		if err != nil {
+			i.t.Fatalf("unable to sign JWT: %+v", err)
+		}
+		signature = b
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		if err != nil {
+			i.t.Fatalf("unable to sign JWT: %+v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// claims is a method that returns valid claims for the subject
+func (i *issuer) claims(sub string) map[string]any {
+	return map[string]any{
+		"iss":    i.server.URL,
+		"aud":    "prometheus-mcp-server",
+		"sub":    sub,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"sre"},
+	}
+}
+
+// TestJWT tests that JWTs are authenticated using the discovered JWKS
+func TestJWT(t *testing.T) {
+	i := newIssuer(t)
+
+	j, err := newJWT(config.OIDC{
+		Issuer:         i.server.URL,
+		Audience:       "prometheus-mcp-server",
+		PrincipalClaim: "sub",
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("unable to create JWT authenticator: %+v", err)
+	}
+
+	// with is a function that returns valid claims with a claim changed (or, if v is nil, removed)
+	with := func(k string, v any) map[string]any {
+		claims := i.claims("alice")
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tamper := i.sign("rsa", i.claims("alice"))
+	tamper = tamper[:len(tamper)-4] + "AAAA"
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{name: "RS256", token: i.sign("rsa", i.claims("alice")), ok: true},
+		{name: "ES256", token: i.sign("ec", i.claims("alice")), ok: true},
+		{name: "audience list", token: i.sign("rsa", with("aud", []string{"other", "prometheus-mcp-server"})), ok: true},
+		{name: "wrong audience", token: i.sign("rsa", with("aud", "other"))},
+		{name: "wrong issuer", token: i.sign("rsa", with("iss", "https://other.example.com"))},
+		{name: "expired", token: i.sign("rsa", with("exp", time.Now().Add(-time.Hour).Unix()))},
+		{name: "no expiry", token: i.sign("rsa", with("exp", nil))},
+		{name: "not yet valid", token: i.sign("rsa", with("nbf", time.Now().Add(time.Hour).Unix()))},
+		{name: "no subject", token: i.sign("rsa", with("sub", nil))},
+		{name: "tampered", token: tamper},
+		{name: "unsigned", token: "eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZSJ9."},
+		{name: "malformed", token: "token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := j.Authenticate(context.Background(), test.token)
+			if (err == nil) != test.ok {
+				t.Fatalf("got: %v; want ok: %t", err, test.ok)
+			}
+			if !test.ok {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("got: %v; want: %v", err, ErrUnauthenticated)
+				}
+				return
+			}
+			if principal.Name != "alice" || len(principal.Groups) != 1 || principal.Groups[0] != "sre" || principal.Method != "jwt" {
+				t.Errorf("got: %+v", principal)
+			}
+		})
+	}
+}
+
+// TestJWTRotate tests that the JWKS is refetched when a JWT is signed by an unknown key
+func TestJWTRotate(t *testing.T) {
+	i := newIssuer(t)
+
+	j, err := newJWT(config.OIDC{
+		Audience:       "prometheus-mcp-server",
+		JWKSURL:        i.server.URL + "/jwks",
+		PrincipalClaim: "sub",
+	}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("unable to create JWT authenticator: %+v", err)
+	}
+
+	i.rotate("new")
+	token := i.sign("new", i.claims("alice"))
+
+	// The JWKS was fetched too recently to be refetched
+	if _, err := j.Authenticate(context.Background(), token); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Mimic the passing of time
+	j.keys.fetched = j.keys.fetched.Add(-2 * minRefresh)
+	if _, err := j.Authenticate(context.Background(), token); err != nil {
+		t.Errorf("expected success: %+v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// token is a type that represents a static bearer token and its principal
+type token struct {
+	Principal string   `yaml:"principal"`
+	Token     string   `yaml:"token"`
+	Groups    []string `yaml:"groups,omitempty"`
+}
+
+// tokensFile is a type that represents the YAML file of static bearer tokens e.g.:
+//
+//	tokens:
+//	- principal: ci
+//	  token: 0123456789abcdef
+//	  groups:
+//	  - sre
+type tokensFile struct {
+	Tokens []token `yaml:"tokens"`
+}
+
+// tokens is a type that represents an Authenticator of static bearer tokens
+// The file is reread when it changes so that tokens may be rotated without restarting
+type tokens struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	tokens  []token
+}
+
+// read is a method that returns the tokens rereading the file if it has changed
+func (t *tokens) read() ([]token, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tokens file %q: %w", t.path, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.modTime.IsZero() && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.tokens, nil
+	}
+
+	b, err := os.ReadFile(t.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tokens file %q: %w", t.path, err)
+	}
+
+	f := tokensFile{}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse tokens file %q: %w", t.path, err)
+	}
+	for i, token := range f.Tokens {
+		if token.Principal == "" || token.Token == "" {
+			return nil, fmt.Errorf("tokens file %q token %d requires a principal and a token", t.path, i)
+		}
+	}
+
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+	t.tokens = f.Tokens
+
+	return t.tokens, nil
+}
+
+// Authenticate is a method that implements Authenticator
+func (t *tokens) Authenticate(ctx context.Context, s string) (*Principal, error) {
+	tokens, err := t.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare digests in constant time so that tokens' lengths and content aren't leaked
+	want := sha256.Sum256([]byte(s))
+	for _, token := range tokens {
+		got := sha256.Sum256([]byte(token.Token))
+		if subtle.ConstantTimeCompare(got[:], want[:]) == 1 {
+			return &Principal{
+				Name:   token.Principal,
+				Groups: token.Groups,
+				Method: "token",
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown token", ErrUnauthenticated)
+}
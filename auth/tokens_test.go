@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// write is a function that writes a file with a modification time
+func write(t *testing.T, path, s string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unable to change file times: %+v", err)
+	}
+}
+
+// TestTokens tests that static tokens are authenticated and reread when the file changes
+func TestTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.yaml")
+	now := time.Now()
+	write(t, path, `tokens:
+- principal: ci
+  token: first
+  groups:
+  - sre
+`, now)
+
+	a := &tokens{
+		path: path,
+	}
+
+	principal, err := a.Authenticate(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if principal.Name != "ci" || len(principal.Groups) != 1 || principal.Groups[0] != "sre" || principal.Method != "token" {
+		t.Errorf("got: %+v", principal)
+	}
+
+	if _, err := a.Authenticate(context.Background(), "second"); err == nil {
+		t.Errorf("expected error")
+	}
+
+	// Rotate the token
+	write(t, path, `tokens:
+- principal: ci
+  token: second
+`, now.Add(time.Minute))
+
+	if _, err := a.Authenticate(context.Background(), "first"); err == nil {
+		t.Errorf("expected error")
+	}
+	if _, err := a.Authenticate(context.Background(), "second"); err != nil {
+		t.Errorf("expected success: %+v", err)
+	}
+}
+
+// TestTokensInvalid tests that tokens without principals are rejected
+func TestTokensInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.yaml")
+	write(t, path, `tokens:
+- token: first
+`, time.Now())
+
+	a := &tokens{
+		path: path,
+	}
+	if _, err := a.read(); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -10,8 +10,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
 	"github.com/DazWilkin/prometheus-mcp-server/config"
 	"github.com/DazWilkin/prometheus-mcp-server/handlers"
+	"github.com/DazWilkin/prometheus-mcp-server/policy"
+	"github.com/DazWilkin/prometheus-mcp-server/prompts"
+	"github.com/DazWilkin/prometheus-mcp-server/transport"
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/prometheus/client_golang/api"
@@ -85,7 +89,7 @@ func getLogger(debug bool) *slog.Logger {
 }
 
 // interceptor is a function that intercepts the HTTP request context
-// The MCP server is configured to use this interceptor but it exists solely to log when it's called
+// It puts the principal authenticated by auth.Middleware (if any) into the MCP request context
 // It is invoked when GitHub Copilot Agent performs MCP server restart|start|stop operations
 // These actions are received as POST requests to the MCP server's endpoint path
 // And the Content_Type is set to "application/json"
@@ -97,10 +101,17 @@ func interceptor(logger *slog.Logger) func(ctx context.Context, r *http.Request)
 		logger.Debug("Entered")
 		defer logger.Debug("Exited")
 
-		// Headers
-		logger.Debug("Headers", "headers", r.Header)
+		// Headers (excluding credentials)
+		headers := r.Header.Clone()
+		headers.Del("Authorization")
+		logger.Debug("Headers", "headers", headers)
+
+		// Principal
+		if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+			logger.Debug("Principal", "principal", principal.Name, "groups", principal.Groups)
+			ctx = auth.WithPrincipal(ctx, principal)
+		}
 
-		// Does nothing
 		return ctx
 	}
 }
@@ -138,13 +149,33 @@ func exporter(c *config.Config, logger *slog.Logger) {
 // The server combines:
 // 1. Prometheus HTTP API (Client) tools
 // 2. Prometheus Metadata (Meta) tools
+// For each of the configured datasources
+// 3. Prometheus HTTP API (Client) resources of the default datasource
+// 4. Prompts that guide investigations using these tools
 func run(c *config.Config, logger *slog.Logger) error {
 	function := "run"
 	logger = logger.With("function", function)
 
+	// Load the policy (if any) that determines the tools and series that principals may access
+	var p *policy.Policy
+	if c.Policy.File != "" {
+		var err error
+		p, err = policy.Load(c.Policy.File)
+		if err != nil {
+			logger.Error("unable to load policy", "err", err)
+			return err
+		}
+		logger.Info("Loaded policy", "policy.file", c.Policy.File, "policies", len(p.Rules))
+	}
+
 	serverOpts := []server.ServerOption{
 		// server.WithToolCapabilities(true),
-		// server.WithResourceCapabilities(true, true),
+		server.WithResourceCapabilities(c.Subscriptions.Enabled(), false),
+		server.WithPromptCapabilities(false),
+	}
+	if p != nil {
+		// Principals only list the tools that they may call
+		serverOpts = append(serverOpts, server.WithToolFilter(p.Filter))
 	}
 	logger.Info("ServerOptions", "opts", serverOpts)
 	s := server.NewMCPServer(
@@ -153,30 +184,73 @@ func run(c *config.Config, logger *slog.Logger) error {
 		serverOpts...,
 	)
 
-	// Create Prometheus Client proxy
-	// TODO(dazwilkin): Naming?
-	// TODO(dazwilkin): {} suggests refactoring to a function
-	{
+	// Create Prometheus Client and Meta proxies for each datasource
+	// Tools are published once and dispatched to datasources by name
+	datasources := make([]handlers.Datasource, len(c.Datasources))
+	for i, d := range c.Datasources {
+		logger := logger.With("datasource", d.Name)
+
+		// Create the RoundTripper shared by the Prometheus API and Management API clients
+		// This authenticates requests and adds any configured headers and tenant
+		rt, err := transport.New(d.HTTPClient)
+		if err != nil {
+			logger.Error("unable to create Prometheus HTTP transport", "err", err)
+			os.Exit(1)
+		}
+
 		// Create Prometheus API client
 		apiClient, err := api.NewClient(api.Config{
-			Address: c.Prometheus,
+			Address:      d.URL,
+			RoundTripper: rt,
 		})
 		if err != nil {
 			logger.Error("unable to create Prometheus API client", "err", err)
 			os.Exit(1)
 		}
 
-		client := handlers.NewClient(apiClient, logger)
-		s.AddTools(client.Tools()...)
+		datasources[i] = handlers.Datasource{
+			Name: d.Name,
+			URL:  d.URL,
+			Client: handlers.NewClient(apiClient, logger,
+				handlers.WithDatasource(d.Name, d.Tenant.Default),
+				handlers.WithAdmin(c.Admin),
+				handlers.WithGuardrails(c.Guardrails),
+				handlers.WithQueryRange(c.QueryRange),
+				handlers.WithResponse(c.Response),
+			),
+			Meta: handlers.NewMeta(d.URL, logger,
+				handlers.WithManagementWrites(c.Management.Writes),
+				handlers.WithRoundTripper(rt),
+				handlers.WithReadyPath(d.ReadyPath()),
+			),
+			Tenant: d.Tenant,
+		}
 	}
+	ds := handlers.NewDatasources(datasources, logger,
+		handlers.WithQueryAll(c.QueryAll),
+		handlers.WithPolicy(p),
+		handlers.WithTools(c.Tools),
+	)
+	s.AddTools(ds.Tools()...)
 
-	// Create Prometheus Meta proxy
-	// TODO(dazwilkin): Naming?
-	// TODO(dazwilkin): {} suggests refactoring to a function
-	{
-		meta := handlers.NewMeta(c.Prometheus, logger)
-		s.AddTools(meta.Tools()...)
+	// Resources are read from the default datasource
+	s.AddResources(ds.Resources()...)
+	s.AddResourceTemplates(ds.ResourceTemplates()...)
+
+	// Prompts are the built-in prompts and those (if any) of --prompts.dir
+	pp, err := prompts.Load(c.Prompts.Dir)
+	if err != nil {
+		logger.Error("unable to load prompts", "err", err)
+		return err
 	}
+	// Only the prompts whose tools are published are published
+	hp := make([]handlers.Prompt, len(pp))
+	for i, prompt := range pp {
+		hp[i] = prompt.HandlersPrompt()
+	}
+	published := ds.Prompts(hp)
+	s.AddPrompts(published...)
+	logger.Info("Loaded prompts", "prompts.dir", c.Prompts.Dir, "prompts", len(pp), "published", len(published))
 
 	stdioOpts := []server.StdioOption{}
 	logger.Info("StdioOptions", "opts", stdioOpts)
@@ -196,12 +270,44 @@ func run(c *config.Config, logger *slog.Logger) error {
 		"server.addr", c.Server.Addr,
 		"server.path", c.Server.Path,
 	)
+
+	// Authenticate requests to the endpoint (if configured)
+	authenticator, err := auth.New(c.Server.Auth, logger)
+	if err != nil {
+		logger.Error("unable to create authenticator", "err", err)
+		return err
+	}
+
+	// Routing is handled here so that the endpoint is wrapped by the authentication middleware
+	mux := http.NewServeMux()
+	httpServer := &http.Server{
+		Addr:    c.Server.Addr,
+		Handler: mux,
+	}
+	// Notifications of resource updates are delivered to (stateful) sessions
+	// The session ID manager is shared with the subscriptions so that only existing sessions may subscribe
+	sessionIDs := &server.InsecureStatefulSessionIdManager{}
 	streamOpts := []server.StreamableHTTPOption{
 		server.WithEndpointPath(c.Server.Path), // Default endpoint path
 		server.WithHTTPContextFunc(interceptor(logger)),
-		server.WithStateLess(true),
+		server.WithStateLess(!c.Subscriptions.Enabled()),
+		server.WithStreamableHTTPServer(httpServer),
+	}
+	if c.Subscriptions.Enabled() {
+		streamOpts = append(streamOpts, server.WithSessionIdManager(sessionIDs))
 	}
-	return server.NewStreamableHTTPServer(s, streamOpts...).Start(c.Server.Addr)
+	streamServer := server.NewStreamableHTTPServer(s, streamOpts...)
+
+	var handler http.Handler = streamServer
+	if c.Subscriptions.Enabled() {
+		// MCPServer doesn't implement resources/subscribe; these requests are handled by the subscriptions' middleware
+		subscriptions := handlers.NewSubscriptions(ds, s, sessionIDs, c.Subscriptions.Interval, logger)
+		go subscriptions.Run(context.Background())
+		handler = subscriptions.Middleware(streamServer)
+	}
+	mux.Handle(c.Server.Path, auth.Middleware(authenticator, logger)(handler))
+
+	return streamServer.Start(c.Server.Addr)
 }
 
 func main() {
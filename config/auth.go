@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+)
+
+// Auth is a type that represents the authentication of requests to the MCP server's (streamable) HTTP endpoint
+// If both are configured, requests may be authenticated using either a static token or a JWT
+type Auth struct {
+	// TokensFile is a YAML file of static bearer tokens and their principals; it is reread when it changes
+	TokensFile string
+	// OIDC configures the validation of OAuth2/OIDC JWTs
+	OIDC OIDC
+}
+
+// GoString is a method that returns a Go string
+func (m Auth) GoString() string {
+	return fmt.Sprintf("Auth{TokensFile: %q, OIDC: %#v}", m.TokensFile, m.OIDC)
+}
+
+// Enabled is a method that returns whether requests must be authenticated
+func (m Auth) Enabled() bool {
+	return m.TokensFile != "" || m.OIDC.Enabled()
+}
+
+// Validate is a method that checks that OIDC is valid
+func (m Auth) Validate() error {
+	return m.OIDC.Validate()
+}
+
+// OIDC is a type that represents the validation of OAuth2/OIDC JWTs
+type OIDC struct {
+	// Issuer is the expected "iss" claim; if JWKSURL is omitted, it is discovered from the issuer's OpenID configuration
+	Issuer string
+	// Audience is the expected "aud" claim
+	Audience string
+	// JWKSURL is the URL of the JSON Web Key Set used to verify JWTs' signatures
+	JWKSURL string
+	// PrincipalClaim is the claim that identifies the principal (default: sub)
+	PrincipalClaim string
+	// GroupsClaim is the claim that lists the principal's groups (default: groups)
+	GroupsClaim string
+}
+
+// GoString is a method that returns a Go string
+func (m OIDC) GoString() string {
+	return fmt.Sprintf("OIDC{Issuer: %q, Audience: %q, JWKSURL: %q, PrincipalClaim: %q, GroupsClaim: %q}", m.Issuer, m.Audience, m.JWKSURL, m.PrincipalClaim, m.GroupsClaim)
+}
+
+// Enabled is a method that returns whether JWTs are validated
+func (m OIDC) Enabled() bool {
+	return m.Issuer != "" || m.JWKSURL != ""
+}
+
+// Validate is a method that checks that JWTs are validated for this server (audience)
+func (m OIDC) Validate() error {
+	if !m.Enabled() {
+		return nil
+	}
+	if m.Audience == "" {
+		msg := "Flag '--server.auth.oidc.audience' is required to validate JWTs"
+		return errors.NewErrConfig(msg, nil)
+	}
+	if m.PrincipalClaim == "" {
+		msg := "Flag '--server.auth.oidc.principal-claim' must not be empty"
+		return errors.NewErrConfig(msg, nil)
+	}
+	return nil
+}
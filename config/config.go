@@ -3,6 +3,8 @@ package config
 import (
 	"flag"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/DazWilkin/prometheus-mcp-server/errors"
 )
@@ -12,12 +14,29 @@ const (
 	Subsystem string = "prometheus"
 )
 
+const (
+	// MaxPoints is the maximum number of points per series that Prometheus returns for a range query
+	MaxPoints uint64 = 11000
+)
+
 // Config is a type that represent the app's configuration
 type Config struct {
-	Prometheus string
-	Server     Server
-	Metric     Metric
-	Debug      bool
+	// Prometheus is the URL of the default (first) datasource
+	Prometheus    string
+	Datasources   Datasources
+	Server        Server
+	Metric        Metric
+	Management    Management
+	Guardrails    Guardrails
+	QueryRange    QueryRange
+	QueryAll      QueryAll
+	Response      Response
+	Policy        Policy
+	Tools         Tools
+	Subscriptions Subscriptions
+	Prompts       Prompts
+	Admin         bool
+	Debug         bool
 }
 
 // NewConfig is a function that creates a new Config
@@ -27,6 +46,15 @@ func NewConfig() (*Config, error) {
 	serverAddr := flag.String("server.addr", ":7777", "Endpoint on which MCP tools are published")
 	serverPath := flag.String("server.path", "/mcp", "Path on which MCP tools are served")
 
+	// MCP server authentication
+	// Requests to the (streamable) HTTP endpoint are authenticated using static bearer tokens and|or OAuth2/OIDC JWTs
+	authTokensFile := flag.String("server.auth.tokens-file", "", "YAML file of static bearer tokens and their principals (reread when it changes)")
+	authOIDCIssuer := flag.String("server.auth.oidc.issuer", "", "Issuer (iss) of OAuth2/OIDC JWTs; the JWKS URL is discovered from the issuer unless configured")
+	authOIDCAudience := flag.String("server.auth.oidc.audience", "", "Audience (aud) of OAuth2/OIDC JWTs")
+	authOIDCJWKSURL := flag.String("server.auth.oidc.jwks-url", "", "URL of the JSON Web Key Set used to verify OAuth2/OIDC JWTs")
+	authOIDCPrincipalClaim := flag.String("server.auth.oidc.principal-claim", "sub", "JWT claim that identifies the principal")
+	authOIDCGroupsClaim := flag.String("server.auth.oidc.groups-claim", "groups", "JWT claim that lists the principal's groups")
+
 	// Metrics config
 	// If metric.addr=="", Prometheus metrics will **not** be exported
 	metricAddr := flag.String("metric.addr", ":8080", "Endpoint on which metrics are published")
@@ -35,6 +63,81 @@ func NewConfig() (*Config, error) {
 	// Prometheus server
 	prometheus := flag.String("prometheus", "http://localhost:9090", "Endpoint of Prometheus server")
 
+	// Datasources
+	// Additional (named) Prometheus servers; tools select these using the 'datasource' argument
+	datasources := Datasources{}
+	flag.Var(&datasources, "datasource", "Named Prometheus server {name}={url} (repeatable)")
+	datasourcesFile := flag.String("datasources.file", "", "YAML file of named Prometheus servers")
+
+	// Prometheus authentication and TLS
+	// Applies to --prometheus and --datasource; datasources in --datasources.file configure their own
+	basicAuthUsername := flag.String("prometheus.basic-auth.username", "", "Username for HTTP basic authentication to Prometheus")
+	basicAuthPasswordFile := flag.String("prometheus.basic-auth.password-file", "", "File containing the password for HTTP basic authentication to Prometheus")
+	bearerTokenFile := flag.String("prometheus.bearer-token-file", "", "File containing a bearer token for Prometheus (reread when it changes)")
+	headers := Headers{}
+	flag.Var(&headers, "prometheus.header", "HTTP header {name}={value} added to requests to Prometheus (repeatable)")
+	tlsCAFile := flag.String("prometheus.tls.ca-file", "", "File containing the CA certificates used to verify Prometheus (reread when it changes)")
+	tlsCertFile := flag.String("prometheus.tls.cert-file", "", "File containing the client certificate for mTLS to Prometheus (reread when it changes)")
+	tlsKeyFile := flag.String("prometheus.tls.key-file", "", "File containing the client key for mTLS to Prometheus (reread when it changes)")
+	tlsServerName := flag.String("prometheus.tls.server-name", "", "Server name used to verify Prometheus' certificate")
+	tlsInsecureSkipVerify := flag.Bool("prometheus.tls.insecure-skip-verify", false, "Disable verification of Prometheus' certificate")
+
+	// Multi-tenant backends (Mimir, Cortex, Thanos)
+	// Applies to --prometheus and --datasource; datasources in --datasources.file configure their own
+	backend := flag.String("prometheus.backend", "prometheus", "Type of Prometheus-compatible server (prometheus, thanos, mimir, cortex); determines the readiness path used by ping")
+	tenantHeader := flag.String("prometheus.tenant.header", DefaultTenantHeader, "Header that identifies the tenant of requests (Thanos uses THANOS-TENANT)")
+	tenantDefault := flag.String("prometheus.tenant", "", "Tenant of requests that don't specify one using the 'tenant' argument")
+	tenantAllowed := Tenants{}
+	flag.Var(&tenantAllowed, "prometheus.tenant.allowed", "Tenant that tools may specify using the 'tenant' argument (repeatable)")
+
+	// Policy
+	// Maps principals (and groups) to the tools they may call and the label matchers added to their queries
+	policyFile := flag.String("policy.file", "", "YAML file of policies that determine the tools and series that principals may access")
+
+	// Tools
+	// Tools (or groups of tools: query, discovery, status, admin, management) that are published
+	tools := Tools{}
+	flag.Var(&tools.Allow, "tools.allow", "Tools or groups of tools (queries, discovery, status, admin, management) that are published; if omitted, every tool is published (repeatable, comma-separated)")
+	flag.Var(&tools.Deny, "tools.deny", "Tools or groups of tools (queries, discovery, status, admin, management) that aren't published (repeatable, comma-separated)")
+	flag.BoolVar(&tools.ReadOnly, "read-only", false, "Publish only tools that don't change Prometheus' state or data")
+
+	// Subscriptions
+	// Resources (alerts, targets) are polled and subscribers are notified when these change
+	subscriptionsInterval := flag.Duration("subscriptions.interval", 0, "Interval at which subscribed resources (alerts, targets) are polled for changes; enables subscriptions and stateful HTTP sessions (0 disables)")
+
+	// Prompts
+	// Built-in prompts (investigations) are supplemented (or replaced) by the prompts in this directory
+	promptsDir := flag.String("prompts.dir", "", "Directory of YAML prompts (runbooks) that supplement (or replace) the built-in prompts")
+
+	// Management API
+	// Methods that change Prometheus' state (reload, quit) must be explicitly enabled
+	managementWrites := flag.Bool("allow-management-writes", false, "Enable Prometheus Management API tools that change state (reload, quit)")
+
+	// TSDB Admin API
+	// Methods that change Prometheus' data (snapshot, delete_series, clean_tombstones) must be explicitly enabled
+	admin := flag.Bool("admin", false, "Enable Prometheus TSDB Admin API tools (snapshot, delete_series, clean_tombstones)")
+
+	// Guardrails
+	// Budgets that protect Prometheus from expensive queries (0 disables the budget)
+	// Budgets are opt-in since estimating the number of series adds requests to every query
+	guardrailsMaxSeries := flag.Uint64("guardrails.max-series", 0, "Maximum (estimated) number of series returned by query, query_range and series (0 disables)")
+	guardrailsMaxPoints := flag.Uint64("guardrails.max-points", 0, "Maximum (estimated) number of points (series x steps) returned by query_range (0 disables)")
+	guardrailsMaxRange := flag.Duration("guardrails.max-range", 0, "Maximum range (end - start) of query_range")
+	guardrailsClamp := flag.Bool("guardrails.clamp", false, "Clamp queries that exceed budgets (limit series, increase step, shorten range) rather than reject them")
+
+	// Query Range
+	// If step is omitted, it's computed from the range to yield (approximately) this number of points
+	queryRangePoints := flag.Uint64("query-range.points", 250, "Target number of points per series when query_range's step is omitted")
+
+	// Query All
+	// query_all queries every datasource concurrently
+	queryAllConcurrency := flag.Int("query-all.concurrency", 4, "Maximum number of datasources queried concurrently by query_all")
+	queryAllTimeout := flag.Duration("query-all.timeout", 30*time.Second, "Timeout of each datasource's query by query_all")
+
+	// Response
+	// Results that exceed this size are truncated and include a cursor to fetch the remainder
+	responseMaxBytes := flag.Int("response.max-bytes", 65536, "Maximum size (bytes; ~4 bytes per token) of tool results; larger results are paged (0 disables)")
+
 	// Debug
 	debug := flag.Bool("debug", false, "Enable debug logging")
 
@@ -46,16 +149,167 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Datasources from flags share the authentication configured by flags
+	httpClient := HTTPClient{
+		BearerTokenFile: *bearerTokenFile,
+		Headers:         headers,
+		TLS: TLS{
+			CAFile:             *tlsCAFile,
+			CertFile:           *tlsCertFile,
+			KeyFile:            *tlsKeyFile,
+			ServerName:         *tlsServerName,
+			InsecureSkipVerify: *tlsInsecureSkipVerify,
+		},
+		Tenant: Tenant{
+			Header:  *tenantHeader,
+			Default: *tenantDefault,
+			Allowed: tenantAllowed,
+		},
+	}
+	if *basicAuthUsername != "" || *basicAuthPasswordFile != "" {
+		httpClient.BasicAuth = &BasicAuth{
+			Username:     *basicAuthUsername,
+			PasswordFile: *basicAuthPasswordFile,
+		}
+	}
+	for i := range datasources {
+		datasources[i].Backend = *backend
+		datasources[i].HTTPClient = httpClient
+	}
+
+	// Datasources from the file precede those from flags
+	if *datasourcesFile != "" {
+		loaded, err := LoadDatasources(*datasourcesFile)
+		if err != nil {
+			return nil, err
+		}
+		datasources = append(loaded, datasources...)
+	}
+
+	// --prometheus is the default datasource unless only named datasources are configured
+	explicit := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "prometheus" {
+			explicit = true
+		}
+	})
+	if explicit || len(datasources) == 0 {
+		datasources = append(Datasources{
+			{
+				Name:       DefaultDatasource,
+				URL:        *prometheus,
+				Backend:    *backend,
+				HTTPClient: httpClient,
+			},
+		}, datasources...)
+	}
+
+	if err := datasources.Validate(); err != nil {
+		return nil, err
+	}
+
+	auth := Auth{
+		TokensFile: *authTokensFile,
+		OIDC: OIDC{
+			Issuer:         *authOIDCIssuer,
+			Audience:       *authOIDCAudience,
+			JWKSURL:        *authOIDCJWKSURL,
+			PrincipalClaim: *authOIDCPrincipalClaim,
+			GroupsClaim:    *authOIDCGroupsClaim,
+		},
+	}
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+
+	// stdio isn't authenticated
+	if auth.Enabled() && *serverAddr == "" {
+		msg := "Flags '--server.auth.*' require '--server.addr'"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	// Read-only mode excludes the tools that these flags enable
+	if tools.ReadOnly && (*managementWrites || *admin) {
+		msg := "Flag '--read-only' conflicts with '--allow-management-writes' and '--admin'"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	if *subscriptionsInterval < 0 {
+		msg := "Flag '--subscriptions.interval' must not be negative"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	// Notifications are delivered using (stateful) HTTP sessions
+	if *subscriptionsInterval != 0 && *serverAddr == "" {
+		msg := "Flag '--subscriptions.interval' requires '--server.addr'"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	if *responseMaxBytes < 0 {
+		msg := "Flag '--response.max-bytes' must not be negative"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	if *queryAllConcurrency < 1 {
+		msg := "Flag '--query-all.concurrency' must be at least 1"
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
+	// Prometheus rejects range queries that return more than 11,000 points per series
+	if *queryRangePoints < 2 || *queryRangePoints > MaxPoints {
+		msg := fmt.Sprintf("Flag '--query-range.points' must be between 2 and %d", MaxPoints)
+		err := errors.NewErrConfig(msg, nil)
+		return nil, err
+	}
+
 	return &Config{
-		Prometheus: *prometheus,
+		Prometheus:  datasources[0].URL,
+		Datasources: datasources,
 		Server: Server{
 			Addr: *serverAddr,
 			Path: *serverPath,
+			Auth: auth,
 		},
 		Metric: Metric{
 			Addr: *metricAddr,
 			Path: *metricPath,
 		},
+		Management: Management{
+			Writes: *managementWrites,
+		},
+		Guardrails: Guardrails{
+			MaxSeries: *guardrailsMaxSeries,
+			MaxPoints: *guardrailsMaxPoints,
+			MaxRange:  *guardrailsMaxRange,
+			Clamp:     *guardrailsClamp,
+		},
+		QueryRange: QueryRange{
+			Points: *queryRangePoints,
+		},
+		QueryAll: QueryAll{
+			Concurrency: *queryAllConcurrency,
+			Timeout:     *queryAllTimeout,
+		},
+		Response: Response{
+			MaxBytes: *responseMaxBytes,
+		},
+		Policy: Policy{
+			File: *policyFile,
+		},
+		Tools: tools,
+		Subscriptions: Subscriptions{
+			Interval: *subscriptionsInterval,
+		},
+		Prompts: Prompts{
+			Dir: *promptsDir,
+		},
+		Admin: *admin,
 		Debug: *debug,
 	}, nil
 }
@@ -65,11 +319,13 @@ func NewConfig() (*Config, error) {
 type Server struct {
 	Addr string
 	Path string
+	// Auth authenticates requests to the HTTP endpoint
+	Auth Auth
 }
 
 // GoString is a method that generates a Go string
 func (m Server) GoString() string {
-	return fmt.Sprintf("MCP{Addr: %q, Path: %q}", m.Addr, m.Path)
+	return fmt.Sprintf("MCP{Addr: %q, Path: %q, Auth: %#v}", m.Addr, m.Path, m.Auth)
 }
 
 // String is a method that generates a string
@@ -93,3 +349,145 @@ func (m Metric) GoString() string {
 func (m Metric) String() string {
 	return fmt.Sprintf("%s/%s", m.Addr, m.Path)
 }
+
+// Management is a type that represents the Prometheus Management API configuration
+type Management struct {
+	// Writes enables Management API methods that change Prometheus' state (reload, quit)
+	Writes bool
+}
+
+// GoString is a method that returns a Go string
+func (m Management) GoString() string {
+	return fmt.Sprintf("Management{Writes: %t}", m.Writes)
+}
+
+// Guardrails is a type that represents the budgets that protect Prometheus from expensive queries
+// A budget of 0 is disabled
+type Guardrails struct {
+	// MaxSeries is the maximum (estimated) number of series returned by query, query_range and series
+	MaxSeries uint64
+	// MaxPoints is the maximum (estimated) number of points (series x steps) returned by query_range
+	MaxPoints uint64
+	// MaxRange is the maximum range (end - start) of query_range
+	MaxRange time.Duration
+	// Clamp queries that exceed budgets rather than reject them
+	Clamp bool
+}
+
+// GoString is a method that returns a Go string
+func (m Guardrails) GoString() string {
+	return fmt.Sprintf("Guardrails{MaxSeries: %d, MaxPoints: %d, MaxRange: %s, Clamp: %t}", m.MaxSeries, m.MaxPoints, m.MaxRange, m.Clamp)
+}
+
+// QueryRange is a type that represents the configuration of range queries
+type QueryRange struct {
+	// Points is the target number of points per series when step is omitted
+	Points uint64
+}
+
+// GoString is a method that returns a Go string
+func (m QueryRange) GoString() string {
+	return fmt.Sprintf("QueryRange{Points: %d}", m.Points)
+}
+
+// QueryAll is a type that represents the configuration of queries of every datasource
+type QueryAll struct {
+	// Concurrency is the maximum number of datasources queried concurrently
+	Concurrency int
+	// Timeout is the timeout of each datasource's query (0 disables)
+	Timeout time.Duration
+}
+
+// GoString is a method that returns a Go string
+func (m QueryAll) GoString() string {
+	return fmt.Sprintf("QueryAll{Concurrency: %d, Timeout: %s}", m.Concurrency, m.Timeout)
+}
+
+// Response is a type that represents the configuration of tool results
+type Response struct {
+	// MaxBytes is the maximum size of tool results; larger results are paged (0 disables)
+	MaxBytes int
+}
+
+// GoString is a method that returns a Go string
+func (m Response) GoString() string {
+	return fmt.Sprintf("Response{MaxBytes: %d}", m.MaxBytes)
+}
+
+// Policy is a type that represents the configuration of access control policies
+type Policy struct {
+	// File is a YAML file of policies; if empty, principals may access every tool and series
+	File string
+}
+
+// GoString is a method that returns a Go string
+func (m Policy) GoString() string {
+	return fmt.Sprintf("Policy{File: %q}", m.File)
+}
+
+// Prompts is a type that represents the configuration of prompts
+type Prompts struct {
+	// Dir is a directory of YAML prompts; if empty, only the built-in prompts are published
+	Dir string
+}
+
+// GoString is a method that returns a Go string
+func (m Prompts) GoString() string {
+	return fmt.Sprintf("Prompts{Dir: %q}", m.Dir)
+}
+
+// Subscriptions is a type that represents the configuration of resource subscriptions
+type Subscriptions struct {
+	// Interval is the interval at which subscribed resources are polled for changes (0 disables)
+	Interval time.Duration
+}
+
+// GoString is a method that returns a Go string
+func (m Subscriptions) GoString() string {
+	return fmt.Sprintf("Subscriptions{Interval: %s}", m.Interval)
+}
+
+// Enabled is a method that returns whether resource subscriptions are enabled
+func (m Subscriptions) Enabled() bool {
+	return m.Interval > 0
+}
+
+// Tools is a type that represents the configuration of the tools that are published
+// Tools are named individually or by group (queries, discovery, status, admin, management)
+type Tools struct {
+	// Allow are the tools that are published; if empty, every tool is published
+	Allow Names
+	// Deny are the tools that aren't published; Deny takes precedence over Allow
+	Deny Names
+	// ReadOnly excludes tools that change Prometheus' state or data
+	ReadOnly bool
+}
+
+// GoString is a method that returns a Go string
+func (m Tools) GoString() string {
+	return fmt.Sprintf("Tools{Allow: %q, Deny: %q, ReadOnly: %t}", m.Allow, m.Deny, m.ReadOnly)
+}
+
+// Names is a type that represents a list of names
+// It implements flag.Value; values are comma-separated and the flag is repeatable
+type Names []string
+
+// String is a method that implements flag.Value
+func (m *Names) String() string {
+	if m == nil {
+		return ""
+	}
+	return strings.Join(*m, ",")
+}
+
+// Set is a method that implements flag.Value
+func (m *Names) Set(s string) error {
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("expected a name")
+		}
+		*m = append(*m, name)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDatasource is the name of the datasource configured by --prometheus
+const DefaultDatasource string = "default"
+
+// name matches valid datasource names e.g. us-east-1, eu_west
+var name = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Backends are the Prometheus-compatible servers and their readiness paths
+// An empty path is Prometheus' {url}/-/ready; other paths are relative to the URL's host since Mimir and Cortex serve the Prometheus API under a prefix e.g. /prometheus
+var Backends = map[string]string{
+	"prometheus": "",
+	"thanos":     "",
+	"mimir":      "/ready",
+	"cortex":     "/ready",
+}
+
+// Datasource is a type that represents a named Prometheus server
+type Datasource struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Backend is the type of Prometheus-compatible server (default: prometheus)
+	Backend string `yaml:"backend,omitempty"`
+	// HTTPClient configures requests to the datasource e.g. authentication
+	HTTPClient `yaml:",inline"`
+}
+
+// GoString is a method that returns a Go string
+func (m Datasource) GoString() string {
+	return fmt.Sprintf("Datasource{Name: %q, URL: %q, Backend: %q, HTTPClient: %#v}", m.Name, m.URL, m.Backend, m.HTTPClient)
+}
+
+// ReadyPath is a method that returns the path of the backend's readiness check
+func (m Datasource) ReadyPath() string {
+	return Backends[m.Backend]
+}
+
+// Datasources is a type that represents a list of datasources
+// It implements flag.Value so that --datasource may be repeated e.g. --datasource=us-east=http://...
+type Datasources []Datasource
+
+// String is a method that implements flag.Value
+func (m *Datasources) String() string {
+	ss := make([]string, len(*m))
+	for i, d := range *m {
+		ss[i] = d.Name + "=" + d.URL
+	}
+	return strings.Join(ss, ",")
+}
+
+// Set is a method that implements flag.Value
+// Values are of the form {name}={url}
+func (m *Datasources) Set(s string) error {
+	n, url, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("expected {name}={url}, got %q", s)
+	}
+	*m = append(*m, Datasource{
+		Name: n,
+		URL:  url,
+	})
+	return nil
+}
+
+// datasourcesFile is a type that represents the YAML file of datasources
+type datasourcesFile struct {
+	Datasources Datasources `yaml:"datasources"`
+}
+
+// LoadDatasources is a function that loads datasources from a YAML file e.g.:
+//
+//	datasources:
+//	- name: us-east-1
+//	  url: http://prometheus.us-east-1:9090
+//	  bearer_token_file: /var/run/secrets/kubernetes.io/serviceaccount/token
+//	  headers:
+//	    X-Team: sre
+func LoadDatasources(path string) (Datasources, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		msg := fmt.Sprintf("unable to read datasources file %q", path)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+
+	f := datasourcesFile{}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		msg := fmt.Sprintf("unable to parse datasources file %q", path)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+
+	return f.Datasources, nil
+}
+
+// Validate is a method that checks that datasources are named uniquely, have URLs and are configured correctly
+func (m Datasources) Validate() error {
+	names := map[string]bool{}
+	for _, d := range m {
+		if !name.MatchString(d.Name) {
+			msg := fmt.Sprintf("datasource name %q is invalid (expected letters, digits, '_', '.' or '-')", d.Name)
+			return errors.NewErrConfig(msg, nil)
+		}
+		if names[d.Name] {
+			msg := fmt.Sprintf("datasource name %q is duplicated", d.Name)
+			return errors.NewErrConfig(msg, nil)
+		}
+		names[d.Name] = true
+
+		if d.URL == "" {
+			msg := fmt.Sprintf("datasource %q requires a URL", d.Name)
+			return errors.NewErrConfig(msg, nil)
+		}
+
+		if _, ok := Backends[d.Backend]; !ok && d.Backend != "" {
+			msg := fmt.Sprintf("datasource %q backend %q is invalid (expected one of: prometheus, thanos, mimir, cortex)", d.Name, d.Backend)
+			return errors.NewErrConfig(msg, nil)
+		}
+
+		if err := d.HTTPClient.Validate(); err != nil {
+			msg := fmt.Sprintf("datasource %q is misconfigured", d.Name)
+			return errors.NewErrConfig(msg, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDatasourcesSet tests that --datasource values are parsed
+func TestDatasourcesSet(t *testing.T) {
+	datasources := Datasources{}
+	for _, s := range []string{
+		"east=http://east:9090",
+		"west=http://west:9090/prometheus?x=y",
+	} {
+		if err := datasources.Set(s); err != nil {
+			t.Fatalf("unable to set %q: %+v", s, err)
+		}
+	}
+
+	want := "east=http://east:9090,west=http://west:9090/prometheus?x=y"
+	if got := datasources.String(); got != want {
+		t.Errorf("got: %s; want: %s", got, want)
+	}
+
+	if err := datasources.Set("http://north:9090"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestDatasourcesValidate tests Validate
+func TestDatasourcesValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		datasources Datasources
+		ok          bool
+	}{
+		{name: "valid", datasources: Datasources{{Name: "east", URL: "http://east:9090"}, {Name: "us-west.2", URL: "http://west:9090"}}, ok: true},
+		{name: "duplicate", datasources: Datasources{{Name: "east", URL: "http://east:9090"}, {Name: "east", URL: "http://west:9090"}}},
+		{name: "invalid name", datasources: Datasources{{Name: "east coast", URL: "http://east:9090"}}},
+		{name: "empty name", datasources: Datasources{{URL: "http://east:9090"}}},
+		{name: "empty URL", datasources: Datasources{{Name: "east"}}},
+		{name: "basic auth and bearer token", datasources: Datasources{{Name: "east", URL: "http://east:9090", HTTPClient: HTTPClient{BasicAuth: &BasicAuth{Username: "admin"}, BearerTokenFile: "/token"}}}},
+		{name: "backend", datasources: Datasources{{Name: "east", URL: "http://mimir:8080/prometheus", Backend: "mimir", HTTPClient: HTTPClient{Tenant: Tenant{Default: "team-a"}}}}, ok: true},
+		{name: "invalid backend", datasources: Datasources{{Name: "east", URL: "http://east:9090", Backend: "victoria"}}},
+		{name: "tenant and header", datasources: Datasources{{Name: "east", URL: "http://east:9090", HTTPClient: HTTPClient{Headers: Headers{"X-Scope-Orgid": "team-a"}, Tenant: Tenant{Default: "team-a"}}}}},
+		{name: "empty allowed tenant", datasources: Datasources{{Name: "east", URL: "http://east:9090", HTTPClient: HTTPClient{Tenant: Tenant{Allowed: Tenants{""}}}}}},
+		{name: "TLS cert without key", datasources: Datasources{{Name: "east", URL: "https://east:9090", HTTPClient: HTTPClient{TLS: TLS{CertFile: "/tls.crt"}}}}},
+	}
+	for _, test := range tests {
+		if err := test.datasources.Validate(); (err == nil) != test.ok {
+			t.Errorf("%s: got: %v; want ok: %t", test.name, err, test.ok)
+		}
+	}
+}
+
+// TestLoadDatasources tests LoadDatasources
+func TestLoadDatasources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "datasources.yaml")
+	b := []byte(`datasources:
+- name: east
+  url: http://east:9090
+- name: west
+  url: http://west:9090
+  basic_auth:
+    username: admin
+    password_file: /etc/prometheus/password
+  headers:
+    X-Team: sre
+  tls_config:
+    ca_file: /etc/prometheus/ca.crt
+    server_name: prometheus.west
+`)
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+
+	got, err := LoadDatasources(path)
+	if err != nil {
+		t.Fatalf("unable to load datasources: %+v", err)
+	}
+	if len(got) != 2 || got[0].Name != "east" || got[1].URL != "http://west:9090" {
+		t.Errorf("got: %#v", got)
+	}
+	if got[0].BasicAuth != nil || got[1].BasicAuth == nil || got[1].BasicAuth.Username != "admin" || got[1].Headers["X-Team"] != "sre" || got[1].TLS.ServerName != "prometheus.west" {
+		t.Errorf("got: %#v", got)
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("unable to validate datasources: %+v", err)
+	}
+}
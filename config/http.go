@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"net/textproto"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+)
+
+// HTTPClient is a type that represents the configuration of HTTP requests to a Prometheus server
+type HTTPClient struct {
+	// BasicAuth authenticates requests using HTTP basic authentication
+	BasicAuth *BasicAuth `yaml:"basic_auth,omitempty"`
+	// BearerTokenFile is a file containing a bearer token; it is reread when it changes so that rotated tokens are used
+	BearerTokenFile string `yaml:"bearer_token_file,omitempty"`
+	// Headers are added to every request
+	Headers Headers `yaml:"headers,omitempty"`
+	// TLS configures TLS (and mTLS) connections
+	TLS TLS `yaml:"tls_config,omitempty"`
+	// Tenant configures the tenant header of multi-tenant backends e.g. Mimir, Cortex and Thanos
+	Tenant Tenant `yaml:"tenant,omitempty"`
+}
+
+// GoString is a method that returns a Go string
+// Header values are redacted since these may contain credentials
+func (m HTTPClient) GoString() string {
+	basicAuth := "nil"
+	if m.BasicAuth != nil {
+		basicAuth = m.BasicAuth.GoString()
+	}
+	return fmt.Sprintf("HTTPClient{BasicAuth: %s, BearerTokenFile: %q, Headers: %s, TLS: %#v, Tenant: %#v}", basicAuth, m.BearerTokenFile, m.Headers.Names(), m.TLS, m.Tenant)
+}
+
+// Validate is a method that checks that at most one authentication method is configured and that TLS and the tenant are valid
+func (m HTTPClient) Validate() error {
+	if m.BasicAuth != nil && m.BearerTokenFile != "" {
+		msg := "at most one of basic auth and bearer token file may be configured"
+		return errors.NewErrConfig(msg, nil)
+	}
+	if m.BasicAuth != nil && m.BasicAuth.Username == "" {
+		msg := "basic auth requires a username"
+		return errors.NewErrConfig(msg, nil)
+	}
+	if err := m.TLS.Validate(); err != nil {
+		return err
+	}
+	if err := m.Tenant.Validate(); err != nil {
+		return err
+	}
+	for name := range m.Headers {
+		if strings.EqualFold(name, "Authorization") && (m.BasicAuth != nil || m.BearerTokenFile != "") {
+			msg := "the Authorization header may not be configured with basic auth or a bearer token file"
+			return errors.NewErrConfig(msg, nil)
+		}
+		if strings.EqualFold(name, m.Tenant.HeaderName()) && m.Tenant.Enabled() {
+			msg := fmt.Sprintf("the %s header may not be configured with a tenant", m.Tenant.HeaderName())
+			return errors.NewErrConfig(msg, nil)
+		}
+	}
+	return nil
+}
+
+// TLS is a type that represents the configuration of TLS connections
+// Files are reread when these change so that rotated certificates are used
+type TLS struct {
+	// CAFile is a file containing the CA certificates used to verify the server (default: the system's)
+	CAFile string `yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile are files containing the client certificate and key (mTLS)
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// ServerName is used to verify the server's certificate (default: the URL's host)
+	ServerName string `yaml:"server_name,omitempty"`
+	// InsecureSkipVerify disables verification of the server's certificate
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// GoString is a method that returns a Go string
+func (m TLS) GoString() string {
+	return fmt.Sprintf("TLS{CAFile: %q, CertFile: %q, KeyFile: %q, ServerName: %q, InsecureSkipVerify: %t}", m.CAFile, m.CertFile, m.KeyFile, m.ServerName, m.InsecureSkipVerify)
+}
+
+// Validate is a method that checks that the client certificate and key are configured together
+func (m TLS) Validate() error {
+	if (m.CertFile == "") != (m.KeyFile == "") {
+		msg := "TLS client certificate and key files must be configured together"
+		return errors.NewErrConfig(msg, nil)
+	}
+	return nil
+}
+
+// DefaultTenantHeader is the header that identifies the tenant of requests to Mimir and Cortex
+const DefaultTenantHeader string = "X-Scope-OrgID"
+
+// Tenant is a type that represents the tenant of requests to multi-tenant backends
+type Tenant struct {
+	// Header identifies the tenant (default: X-Scope-OrgID); Thanos uses THANOS-TENANT
+	Header string `yaml:"header,omitempty"`
+	// Default is the tenant of requests that don't specify one
+	Default string `yaml:"default,omitempty"`
+	// Allowed are the tenants that tools may specify using the 'tenant' argument
+	Allowed Tenants `yaml:"allowed,omitempty"`
+}
+
+// GoString is a method that returns a Go string
+func (m Tenant) GoString() string {
+	return fmt.Sprintf("Tenant{Header: %q, Default: %q, Allowed: %+q}", m.Header, m.Default, []string(m.Allowed))
+}
+
+// Enabled is a method that returns whether requests identify a tenant
+func (m Tenant) Enabled() bool {
+	return m.Default != "" || len(m.Allowed) != 0
+}
+
+// HeaderName is a method that returns the (canonical) name of the header that identifies the tenant
+func (m Tenant) HeaderName() string {
+	if m.Header == "" {
+		return DefaultTenantHeader
+	}
+	return textproto.CanonicalMIMEHeaderKey(m.Header)
+}
+
+// Allows is a method that returns whether tools may specify the tenant
+func (m Tenant) Allows(tenant string) bool {
+	return slices.Contains(m.Allowed, tenant)
+}
+
+// Validate is a method that checks that the header and allowed tenants are valid
+func (m Tenant) Validate() error {
+	if strings.ContainsAny(m.Header, " :") {
+		msg := fmt.Sprintf("tenant header %q is invalid", m.Header)
+		return errors.NewErrConfig(msg, nil)
+	}
+	if slices.Contains(m.Allowed, "") {
+		msg := "allowed tenants may not be empty"
+		return errors.NewErrConfig(msg, nil)
+	}
+	return nil
+}
+
+// Tenants is a type that represents a list of tenants
+// It implements flag.Value so that --prometheus.tenant.allowed may be repeated e.g. --prometheus.tenant.allowed=team-a
+type Tenants []string
+
+// String is a method that implements flag.Value
+func (m *Tenants) String() string {
+	if m == nil {
+		return ""
+	}
+	return strings.Join(*m, ",")
+}
+
+// Set is a method that implements flag.Value
+func (m *Tenants) Set(s string) error {
+	if s == "" {
+		return fmt.Errorf("expected a tenant")
+	}
+	*m = append(*m, s)
+	return nil
+}
+
+// BasicAuth is a type that represents HTTP basic authentication credentials
+type BasicAuth struct {
+	Username string `yaml:"username"`
+	// PasswordFile is a file containing the password; it is reread when it changes
+	PasswordFile string `yaml:"password_file"`
+}
+
+// GoString is a method that returns a Go string
+func (m BasicAuth) GoString() string {
+	return fmt.Sprintf("BasicAuth{Username: %q, PasswordFile: %q}", m.Username, m.PasswordFile)
+}
+
+// Headers is a type that represents HTTP headers added to requests
+// It implements flag.Value so that --prometheus.header may be repeated e.g. --prometheus.header=X-Team=sre
+type Headers map[string]string
+
+// Names is a method that returns the (sorted) header names
+func (m Headers) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String is a method that implements flag.Value
+// Header values are omitted since these may contain credentials
+func (m *Headers) String() string {
+	if m == nil {
+		return ""
+	}
+	return strings.Join(m.Names(), ",")
+}
+
+// Set is a method that implements flag.Value
+// Values are of the form {name}={value}
+func (m *Headers) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected {name}={value}, got %q", s)
+	}
+	if *m == nil {
+		*m = Headers{}
+	}
+	(*m)[textproto.CanonicalMIMEHeaderKey(name)] = value
+	return nil
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/transport"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// Maximum number of series included as examples in delete_series dry-run results
+	deleteSeriesExamples int = 10
+	// Duration for which delete_series dry-run confirmations are valid
+	confirmationTTL time.Duration = 5 * time.Minute
+)
+
+// DeleteSeriesDryRun is a type that represents the result of a delete_series dry-run
+type DeleteSeriesDryRun struct {
+	Datasource string           `json:"datasource,omitempty"`
+	Tenant     string           `json:"tenant,omitempty"`
+	Matches    []string         `json:"matches"`
+	Start      string           `json:"start,omitempty"`
+	End        string           `json:"end,omitempty"`
+	Series     int              `json:"series"`
+	Examples   []model.LabelSet `json:"examples"`
+	Confirm    string           `json:"confirm"`
+	Message    string           `json:"message"`
+}
+
+// DeleteSeriesResult is a type that represents the result of a delete_series
+type DeleteSeriesResult struct {
+	Datasource string   `json:"datasource,omitempty"`
+	Tenant     string   `json:"tenant,omitempty"`
+	Matches    []string `json:"matches"`
+	Start      string   `json:"start,omitempty"`
+	End        string   `json:"end,omitempty"`
+	Series     int      `json:"series"`
+	Message    string   `json:"message"`
+}
+
+// newKey is a function that creates a random key
+// The key is used to sign dry-run confirmations so these can't be forged by the caller
+func newKey() []byte {
+	key := make([]byte, 32)
+	// rand.Read never returns an error
+	_, _ = rand.Read(key)
+	return key
+}
+
+// deleteTenant is a method that returns the tenant of a delete_series request
+// This is the call's tenant (see Datasources.tenant) or the datasource's default tenant
+func (x *Client) deleteTenant(ctx context.Context) string {
+	if tenant, ok := transport.TenantFrom(ctx); ok {
+		return tenant
+	}
+	return x.tenant
+}
+
+// confirmation is a method that signs a delete_series dry-run: its request (datasource, tenant, matches, start, end),
+// the time of the dry-run and the number of series that it selected
+// A confirmation is only valid for the datasource and tenant of the dry-run
+// The confirmation includes the time and the number of series (as well as the signature) so that these can be checked before series are deleted (see parseConfirmation)
+func (x *Client) confirmation(tenant string, matches []string, start, end, issued time.Time, series int) string {
+	h := hmac.New(sha256.New, x.key)
+	h.Write([]byte(x.datasource))
+	h.Write([]byte{0})
+	h.Write([]byte(tenant))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(matches, "\x00")))
+	h.Write([]byte{0})
+	h.Write([]byte(start.UTC().Format(time.RFC3339Nano)))
+	h.Write([]byte{0})
+	h.Write([]byte(end.UTC().Format(time.RFC3339Nano)))
+	h.Write([]byte{0})
+	h.Write(strconv.AppendInt(nil, issued.Unix(), 10))
+	h.Write([]byte{0})
+	h.Write(strconv.AppendInt(nil, int64(series), 10))
+	return fmt.Sprintf("%d.%d.%s", issued.Unix(), series, hex.EncodeToString(h.Sum(nil))[:16])
+}
+
+// parseConfirmation is a function that returns the time of the dry-run and the number of series of a confirmation
+// The confirmation must (also) be verified using confirmation
+func parseConfirmation(confirm string) (time.Time, int, bool) {
+	parts := strings.Split(confirm, ".")
+	if len(parts) != 3 {
+		return time.Time{}, 0, false
+	}
+	issued, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	series, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return time.Unix(issued, 0), series, true
+}
+
+// adminTools is a method that returns the MCP server tools implemented by Client for the TSDB Admin API
+// These require that Prometheus be started with --web.enable-admin-api
+func (x *Client) adminTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool(
+				"clean_tombstones",
+				mcp.WithDescription("Prometheus TSDB Admin: Remove deleted data from disk and clean up existing tombstones (requires --web.enable-admin-api)"),
+				mcp.WithDestructiveHintAnnotation(true),
+			),
+			Handler: x.CleanTombstones,
+		},
+		{
+			Tool: mcp.NewTool(
+				"delete_series",
+				mcp.WithDescription("Prometheus TSDB Admin: Delete data for a selection of series (requires --web.enable-admin-api). Always performs a dry-run first that reports the series that would be deleted and a 'confirm' value. Repeat the call with the same arguments and 'confirm' to delete the series."),
+				mcp.WithDestructiveHintAnnotation(true),
+				mcp.WithArray("match[]",
+					mcp.Items(map[string]any{"type": "string"}),
+					mcp.Required(),
+					mcp.Description("Repeated series selector argument that selects the series to delete"),
+				),
+				mcp.WithString("start",
+					mcp.Description("Start timestamp (RFC-3339 or Unix epoch; relative times aren't accepted)"),
+				),
+				mcp.WithString("end",
+					mcp.Description("End timestamp (RFC-3339 or Unix epoch; relative times aren't accepted)"),
+				),
+				mcp.WithString("confirm",
+					mcp.Description("Confirmation value returned by the dry-run; when omitted, only the dry-run is performed"),
+				),
+			),
+			Handler: x.DeleteSeries,
+		},
+		{
+			Tool: mcp.NewTool(
+				"snapshot",
+				mcp.WithDescription("Prometheus TSDB Admin: Create a snapshot of all current data (requires --web.enable-admin-api)"),
+				mcp.WithDestructiveHintAnnotation(false),
+				mcp.WithBoolean("skip_head",
+					mcp.Description("Skip data present in the head block"),
+				),
+			),
+			Handler: x.Snapshot,
+		},
+	}
+}
+
+// CleanTombstones is a method that removes deleted data from disk and cleans up existing tombstones
+func (x *Client) CleanTombstones(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "CleanTombstones"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus CleanTombstones method
+	if err := x.v1api.CleanTombstones(ctx); err != nil {
+		msg := "unable to clean tombstones"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Warn("Tombstones cleaned")
+
+	return mcp.NewToolResultText("OK"), nil
+}
+
+// DeleteSeries is a method that deletes data for a selection of series
+// It requires a dry-run (without 'confirm') that reports the series that would be deleted
+// The dry-run returns a confirmation that must be provided (with the same arguments) to delete the series
+func (x *Client) DeleteSeries(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "DeleteSeries"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// required: match[]
+	// optional: start, end, confirm
+	args := rqst.GetArguments()
+
+	// Required
+	matches, err := extractMatches(args["match[]"], logger)
+	if err != nil {
+		msg := "unable to extract repeated 'match[]' parameters"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	// Timestamps must be absolute so that the dry-run and the deletion select the same time range
+	startTime, err := extractAbsoluteTimestamp(args["start"], logger)
+	if err != nil {
+		msg := "unable to extract 'start' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	endTime, err := extractAbsoluteTimestamp(args["end"], logger)
+	if err != nil {
+		msg := "unable to extract 'end' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	confirm, _ := args["confirm"].(string)
+	tenant := x.deleteTenant(ctx)
+
+	// Dry-run
+	if confirm == "" {
+		// Invoke Prometheus Series method with the same matchers
+		series, warnings, err := x.v1api.Series(ctx, matches, startTime, endTime)
+		if err != nil {
+			msg := "unable to retrieve series for dry-run"
+			return Err(method, msg, err, logger)
+		}
+
+		// If there are warnings, log them
+		if len(warnings) != 0 {
+			logger.Info("Warnings", "warnings", warnings)
+		}
+
+		logger.Info("Delete series dry-run",
+			"datasource", x.datasource,
+			"tenant", tenant,
+			"matches", matches,
+			"start", formatTimestamp(startTime),
+			"end", formatTimestamp(endTime),
+			"series", len(series),
+		)
+
+		examples := series[:min(len(series), deleteSeriesExamples)]
+		result := DeleteSeriesDryRun{
+			Datasource: x.datasource,
+			Tenant:     tenant,
+			Matches:    matches,
+			Start:      formatTimestamp(startTime),
+			End:        formatTimestamp(endTime),
+			Series:     len(series),
+			Examples:   examples,
+			Confirm:    x.confirmation(tenant, matches, startTime, endTime, time.Now(), len(series)),
+			Message:    fmt.Sprintf("Dry-run: no series were deleted. To delete these series, repeat the call with the same arguments and 'confirm' within %s", confirmationTTL),
+		}
+
+		b, err := json.Marshal(result)
+		if err != nil {
+			msg := "unable to marshal dry-run"
+			return Err(method, msg, err, logger)
+		}
+
+		return mcp.NewToolResultText(string(b)), nil
+	}
+
+	// Delete
+	// The confirmation must be of the same arguments and unexpired
+	issued, confirmed, ok := parseConfirmation(confirm)
+	if !ok || !hmac.Equal([]byte(confirm), []byte(x.confirmation(tenant, matches, startTime, endTime, issued, confirmed))) {
+		msg := "'confirm' does not match the arguments; perform a dry-run (without 'confirm') using the same arguments first"
+		return Err(method, msg, nil, logger)
+	}
+	if age := time.Since(issued); age > confirmationTTL {
+		msg := fmt.Sprintf("'confirm' expired (the dry-run was %s ago; confirmations are valid for %s); perform a dry-run (without 'confirm') again", age.Round(time.Second), confirmationTTL)
+		return Err(method, msg, nil, logger)
+	}
+
+	// Count the series (again) so that the deletion is recorded
+	// The series must be those of the dry-run (by number) e.g. series that were added since the dry-run aren't deleted
+	series, _, err := x.v1api.Series(ctx, matches, startTime, endTime)
+	if err != nil {
+		msg := "unable to retrieve series"
+		return Err(method, msg, err, logger)
+	}
+	if len(series) != confirmed {
+		msg := fmt.Sprintf("the arguments select %d series but the dry-run selected %d; perform a dry-run (without 'confirm') again", len(series), confirmed)
+		return Err(method, msg, nil, logger)
+	}
+
+	// Record the deletion (audit)
+	logger.Warn("Deleting series",
+		"datasource", x.datasource,
+		"tenant", tenant,
+		"matches", matches,
+		"start", formatTimestamp(startTime),
+		"end", formatTimestamp(endTime),
+		"series", len(series),
+	)
+
+	// Invoke Prometheus DeleteSeries method
+	if err := x.v1api.DeleteSeries(ctx, matches, startTime, endTime); err != nil {
+		msg := "unable to delete series"
+		return Err(method, msg, err, logger)
+	}
+
+	result := DeleteSeriesResult{
+		Datasource: x.datasource,
+		Tenant:     tenant,
+		Matches:    matches,
+		Start:      formatTimestamp(startTime),
+		End:        formatTimestamp(endTime),
+		Series:     len(series),
+		Message:    "Series deleted. Data is removed from disk by clean_tombstones (or compaction)",
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		msg := "unable to marshal delete series result"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// Snapshot is a method that creates a snapshot of all current data
+func (x *Client) Snapshot(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Snapshot"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// optional: skip_head
+	args := rqst.GetArguments()
+
+	// Optional
+	skipHead, _ := args["skip_head"].(bool)
+
+	// Invoke Prometheus Snapshot method
+	result, err := x.v1api.Snapshot(ctx, skipHead)
+	if err != nil {
+		msg := "unable to create snapshot"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Warn("Snapshot created",
+		"name", result.Name,
+	)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		msg := "unable to marshal snapshot"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
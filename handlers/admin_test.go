@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/testdata"
+	"github.com/DazWilkin/prometheus-mcp-server/transport"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestClientToolsAdmin tests that TSDB Admin API tools are only published when enabled
+func TestClientToolsAdmin(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: p,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	names := func(c *Client) []string {
+		nn := []string{}
+		for _, tool := range c.Tools() {
+			nn = append(nn, tool.Tool.Name)
+		}
+		return nn
+	}
+
+	admin := []string{"clean_tombstones", "delete_series", "snapshot"}
+
+	{
+		got := names(NewClient(apiClient, logger))
+		for _, name := range admin {
+			if slices.Contains(got, name) {
+				t.Errorf("expected no '%s' tool", name)
+			}
+		}
+	}
+	{
+		got := names(NewClient(apiClient, logger, WithAdmin(true)))
+		for _, name := range admin {
+			if !slices.Contains(got, name) {
+				t.Errorf("expected '%s' tool", name)
+			}
+		}
+	}
+}
+
+// TestDeleteSeries tests DeleteSeries' dry-run and confirmation
+// https://prometheus.io/docs/prometheus/latest/querying/api/#delete-series
+func TestDeleteSeries(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// If empty, the series selected by the dry-run no longer exist
+	empty := false
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		data := testdata.JsonSeries
+		if empty {
+			data = []byte("[]")
+		}
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	deleted := 0
+	mux.HandleFunc("/api/v1/admin/tsdb/delete_series", func(w http.ResponseWriter, r *http.Request) {
+		deleted++
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger, WithAdmin(true))
+
+	call := func(args map[string]any) (*mcp.CallToolResult, error) {
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name:      "DeleteSeries",
+				Arguments: args,
+			},
+		}
+		return c.DeleteSeries(context.Background(), rqst)
+	}
+
+	matches := []any{`up{job="prometheus"}`}
+
+	// Dry-run
+	resp, err := call(map[string]any{
+		"match[]": matches,
+	})
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+
+	dryrun := DeleteSeriesDryRun{}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &dryrun); err != nil {
+		t.Fatalf("unable to unmarshal dry-run: %+v", err)
+	}
+
+	if dryrun.Series != len(testdata.Series) {
+		t.Errorf("got: %d; want: %d", dryrun.Series, len(testdata.Series))
+	}
+	if dryrun.Confirm == "" {
+		t.Errorf("expected confirmation")
+	}
+	if deleted != 0 {
+		t.Errorf("dry-run must not delete series")
+	}
+
+	// Confirmation that does not match the arguments
+	if _, err := call(map[string]any{
+		"match[]": []any{"up"},
+		"confirm": dryrun.Confirm,
+	}); err == nil {
+		t.Errorf("expected error")
+	}
+	if deleted != 0 {
+		t.Errorf("mismatched confirmation must not delete series")
+	}
+
+	// Confirmation that matches the arguments
+	if _, err := call(map[string]any{
+		"match[]": matches,
+		"confirm": dryrun.Confirm,
+	}); err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("got: %d deletions; want: 1", deleted)
+	}
+
+	// Relative times resolve differently on the dry-run and the deletion so these aren't accepted
+	if _, err := call(map[string]any{
+		"match[]": matches,
+		"start":   "now-1h",
+	}); err == nil {
+		t.Errorf("expected error")
+	}
+
+	// Unix epochs are absolute
+	args := map[string]any{
+		"match[]": matches,
+		"start":   "1749808800",
+		"end":     1749812400.0,
+	}
+	resp, err = call(args)
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &dryrun); err != nil {
+		t.Fatalf("unable to unmarshal dry-run: %+v", err)
+	}
+	args["confirm"] = dryrun.Confirm
+	if _, err := call(args); err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("got: %d deletions; want: 2", deleted)
+	}
+
+	// Expired confirmation
+	expired := c.confirmation("", []string{`up{job="prometheus"}`}, time.Time{}, time.Time{}, time.Now().Add(-2*confirmationTTL), len(testdata.Series))
+	if _, err := call(map[string]any{
+		"match[]": matches,
+		"confirm": expired,
+	}); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("got: %v; want: expired error", err)
+	}
+
+	// Confirmation whose number of series differs from the number of series that the arguments select
+	resp, err = call(map[string]any{
+		"match[]": matches,
+	})
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &dryrun); err != nil {
+		t.Fatalf("unable to unmarshal dry-run: %+v", err)
+	}
+	empty = true
+	if _, err := call(map[string]any{
+		"match[]": matches,
+		"confirm": dryrun.Confirm,
+	}); err == nil || !strings.Contains(err.Error(), "dry-run selected") {
+		t.Errorf("got: %v; want: series error", err)
+	}
+	if deleted != 2 {
+		t.Errorf("got: %d deletions; want: 2", deleted)
+	}
+}
+
+// TestDeleteSeriesTenant tests that confirmations are only valid for the datasource and tenant of the dry-run
+func TestDeleteSeriesTenant(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := fmt.Fprintf(w, `{"data":%s,"status":"success"}`, testdata.JsonSeries); err != nil {
+			t.Logf("error encoding JSON: %+q", err)
+		}
+	})
+
+	deleted := 0
+	mux.HandleFunc("/api/v1/admin/tsdb/delete_series", func(w http.ResponseWriter, r *http.Request) {
+		deleted++
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	east := NewClient(apiClient, logger, WithAdmin(true), WithDatasource("east", "a"))
+	// A datasource that (hypothetically) shares the key
+	west := NewClient(apiClient, logger, WithAdmin(true), WithDatasource("west", "a"))
+	west.key = east.key
+
+	call := func(ctx context.Context, c *Client, confirm string) (*mcp.CallToolResult, error) {
+		args := map[string]any{
+			"match[]": []any{`up{job="prometheus"}`},
+		}
+		if confirm != "" {
+			args["confirm"] = confirm
+		}
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name:      "DeleteSeries",
+				Arguments: args,
+			},
+		}
+		return c.DeleteSeries(ctx, rqst)
+	}
+
+	// Dry-run using the default tenant (a)
+	resp, err := call(context.Background(), east, "")
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	dryrun := DeleteSeriesDryRun{}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &dryrun); err != nil {
+		t.Fatalf("unable to unmarshal dry-run: %+v", err)
+	}
+	if dryrun.Datasource != "east" || dryrun.Tenant != "a" {
+		t.Errorf("got: %s/%s; want: east/a", dryrun.Datasource, dryrun.Tenant)
+	}
+
+	// Another tenant
+	if _, err := call(transport.WithTenant(context.Background(), "b"), east, dryrun.Confirm); err == nil {
+		t.Errorf("expected error")
+	}
+	// Another datasource
+	if _, err := call(context.Background(), west, dryrun.Confirm); err == nil {
+		t.Errorf("expected error")
+	}
+	if deleted != 0 {
+		t.Fatalf("got: %d deletions; want: 0", deleted)
+	}
+
+	// The tenant of the dry-run (explicitly)
+	if _, err := call(transport.WithTenant(context.Background(), "a"), east, dryrun.Confirm); err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("got: %d deletions; want: 1", deleted)
+	}
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/render"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// cursorItems cursors are offsets into (sorted) results e.g. series
+	cursorItems string = "i"
+	// cursorElements cursors are offsets into the elements of a (JSON) result (see elements)
+	cursorElements string = "e"
+	// cursorBytes cursors are offsets into a (non-JSON) result's text
+	cursorBytes string = "b"
+)
+
+// paged are the tools whose results are lists (e.g. of series, samples or label values) that may exceed the response budget
+// Other tools' results are small (or can't be paged) and aren't subject to the budget
+var paged = []string{
+	"query",
+	"query_range",
+	"query_all",
+	"series",
+	"exemplars",
+	"labels",
+	"label_values",
+	"metrics",
+	"metadata",
+	"targets",
+	"targets_metadata",
+	"alerts",
+	"rules",
+}
+
+// WithResponse is a function that configures the response budget
+func WithResponse(response config.Response) ClientOption {
+	return func(x *Client) {
+		x.response = response
+	}
+}
+
+// cursor is a type that represents the position of a page in a (paged) result
+// now is the time at which the first page was fetched; later pages resolve omitted and relative times (e.g. now-1h) from it
+// so that every page is of the same result
+type cursor struct {
+	kind   string
+	offset int
+	now    time.Time
+}
+
+// next is a method that returns the cursor of the page that follows a page of n items (or elements or bytes)
+func (c cursor) next(n int) cursor {
+	return cursor{kind: c.kind, offset: c.offset + n, now: c.now}
+}
+
+// nowKey is the context key of the time from which the tools resolve omitted and relative times
+type nowKey struct{}
+
+// withNow is a function that returns a copy of ctx with the time from which omitted and relative times are resolved
+func withNow(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
+
+// nowFrom is a function that returns the time from which omitted and relative times are resolved
+// If ctx has no time (see withNow), it is now
+func nowFrom(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(nowKey{}).(time.Time); ok {
+		return now
+	}
+	return time.Now()
+}
+
+// encodeCursor is a function that encodes a cursor as an (opaque) string
+func encodeCursor(c cursor) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%s:%d:%d", c.kind, c.offset, c.now.UnixNano()))
+}
+
+// decodeCursor is a function that decodes a cursor
+func decodeCursor(s string) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 3 {
+		return cursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	kind := parts[0]
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 || (kind != cursorItems && kind != cursorElements && kind != cursorBytes) {
+		return cursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	now, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now <= 0 {
+		return cursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	return cursor{kind: kind, offset: offset, now: time.Unix(0, now).UTC()}, nil
+}
+
+// extractCursor is a function that extracts the position in (sorted) results from a cursor argument
+// If omitted, the cursor is of the first page and its time is now
+func extractCursor(x any, now time.Time, logger *slog.Logger) (cursor, error) {
+	s, ok := x.(string)
+	if !ok || s == "" {
+		return cursor{kind: cursorItems, now: now}, nil
+	}
+
+	c, err := decodeCursor(s)
+	if err == nil && c.kind != cursorItems {
+		err = fmt.Errorf("cursor %q isn't valid for this tool", s)
+	}
+	if err != nil {
+		msg := "unable to parse cursor"
+		logger.Error(msg, "err", err)
+		return cursor{}, errors.NewErrToolHandler(msg, err)
+	}
+
+	return c, nil
+}
+
+// page is a function that renders the largest page of items, starting at offset, within maxBytes
+// It returns the rendered page and the number of items it contains
+// Pages contain at least one item (even if it exceeds maxBytes) so that paging always progresses
+// Offsets beyond the last item are rejected
+func page[T any](items []T, offset, maxBytes int, f func([]T) (string, error)) (string, int, error) {
+	if offset != 0 && offset >= len(items) {
+		return "", 0, fmt.Errorf("cursor is beyond the end of the results (%d)", len(items))
+	}
+	rest := items[offset:]
+
+	text, err := f(rest)
+	if err != nil || maxBytes == 0 || len(text) <= maxBytes || len(rest) <= 1 {
+		return text, len(rest), err
+	}
+
+	// The size of a page increases with the number of items so binary search for the largest page that fits
+	best, bestText := 0, ""
+	lo, hi := 1, len(rest)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		text, err := f(rest[:mid])
+		if err != nil {
+			return "", 0, err
+		}
+		if len(text) <= maxBytes {
+			best, bestText = mid, text
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if best == 0 {
+		text, err := f(rest[:1])
+		return text, 1, err
+	}
+
+	return bestText, best, nil
+}
+
+// pageNotes is a function that describes a page of results and, if there are more, the cursor (of the kind) to fetch the next page
+// It returns no notes if the page contains all the results
+func pageNotes(c cursor, noun, order string, n, total int, omitted string) []string {
+	offset := c.offset
+	if offset == 0 && n == total {
+		return nil
+	}
+
+	note := fmt.Sprintf("page: %s %d-%d of %d", noun, offset+1, offset+n, total)
+	if order != "" {
+		note += fmt.Sprintf(" (sorted by %s)", order)
+	}
+	if rest := total - offset - n; rest > 0 {
+		note += fmt.Sprintf("; %d %s%s omitted; pass cursor=%q to fetch the next page", rest, noun, omitted, encodeCursor(c.next(n)))
+	}
+
+	return []string{note}
+}
+
+// pageValue is a method that sorts and renders a page of query results within the response budget
+// Vectors are sorted by value (descending) so that the first page contains the top series
+// Matrices are sorted by labels
+func (x *Client) pageValue(value model.Value, format render.Format, c cursor) (string, []string, error) {
+	switch v := value.(type) {
+	case model.Vector:
+		sortVector(v)
+		text, n, err := page(v, c.offset, x.response.MaxBytes, func(ss []*model.Sample) (string, error) {
+			vector := model.Vector(ss)
+			return output(format, vector, func() *render.Result {
+				return render.Value(vector)
+			})
+		})
+		return text, pageNotes(c, "series", "value, descending", n, len(v), ""), err
+	case model.Matrix:
+		sort.Sort(v)
+		text, n, err := page(v, c.offset, x.response.MaxBytes, func(ss []*model.SampleStream) (string, error) {
+			matrix := model.Matrix(ss)
+			return output(format, matrix, func() *render.Result {
+				return render.Value(matrix)
+			})
+		})
+
+		// Report the number of samples omitted
+		samples := 0
+		for _, stream := range v[min(c.offset+n, len(v)):] {
+			samples += len(stream.Values) + len(stream.Histograms)
+		}
+		omitted := fmt.Sprintf(" (%d samples)", samples)
+
+		return text, pageNotes(c, "series", "labels", n, len(v), omitted), err
+	default:
+		if c.offset != 0 {
+			return "", nil, fmt.Errorf("cursor is beyond the end of the results (1)")
+		}
+		text, err := output(format, value, func() *render.Result {
+			return render.Value(value)
+		})
+		return text, nil, err
+	}
+}
+
+// pageSummaries is a method that renders a page of range query summaries within the response budget
+// Summaries are in the order of the (sorted) matrix i.e. by labels
+func (x *Client) pageSummaries(summaries []render.Summary, format render.Format, c cursor) (string, []string, error) {
+	text, n, err := page(summaries, c.offset, x.response.MaxBytes, func(ss []render.Summary) (string, error) {
+		return output(format, ss, func() *render.Result {
+			return render.Summaries(ss)
+		})
+	})
+	return text, pageNotes(c, "series", "labels", n, len(summaries), ""), err
+}
+
+// pageSeries is a method that sorts and renders a page of series within the response budget
+func (x *Client) pageSeries(series []model.LabelSet, format render.Format, c cursor) (string, []string, error) {
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Before(series[j])
+	})
+	text, n, err := page(series, c.offset, x.response.MaxBytes, func(ss []model.LabelSet) (string, error) {
+		return output(format, ss, func() *render.Result {
+			return render.Series(ss)
+		})
+	})
+	return text, pageNotes(c, "series", "labels", n, len(series), ""), err
+}
+
+// sortVector is a function that sorts a vector by value (descending) and then by labels
+// NaN values are last
+func sortVector(v model.Vector) {
+	sort.SliceStable(v, func(i, j int) bool {
+		a, b := float64(v[i].Value), float64(v[j].Value)
+		switch {
+		case math.IsNaN(a) || math.IsNaN(b):
+			if math.IsNaN(a) != math.IsNaN(b) {
+				return !math.IsNaN(a)
+			}
+		case a != b:
+			return a > b
+		}
+		return v[i].Metric.Before(v[j].Metric)
+	})
+}
+
+// element is a type that represents an element of a JSON result
+// Elements are the items of the result's arrays and the result's other fields
+type element struct {
+	// key is the field of an object result (if any)
+	key   string
+	value json.RawMessage
+	// item is true if value is an item of an array (rather than a field's value)
+	item bool
+}
+
+// elements is a function that splits a JSON result (an array or an object) into its elements
+// Pages of elements are valid JSON (see marshalElements); object fields are in key order
+// It returns false if the result isn't a JSON array or object
+func elements(text string) ([]element, bool, bool) {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(text), &items); err == nil && items != nil {
+		ee := make([]element, len(items))
+		for i, item := range items {
+			ee[i] = element{value: item, item: true}
+		}
+		return ee, false, true
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &fields); err != nil || fields == nil {
+		return nil, false, false
+	}
+
+	ee := []element{}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(fields[key], &items); err != nil || len(items) == 0 {
+			ee = append(ee, element{key: key, value: fields[key]})
+			continue
+		}
+		for _, item := range items {
+			ee = append(ee, element{key: key, value: item, item: true})
+		}
+	}
+	return ee, true, true
+}
+
+// marshalElements is a function that joins elements into a JSON array or object
+// Array fields contain (only) the items that are elements
+func marshalElements(ee []element, object bool) (string, error) {
+	var v any
+	if object {
+		fields := map[string]any{}
+		for _, e := range ee {
+			if !e.item {
+				fields[e.key] = e.value
+				continue
+			}
+			items, _ := fields[e.key].([]json.RawMessage)
+			fields[e.key] = append(items, e.value)
+		}
+		v = fields
+	} else {
+		items := make([]json.RawMessage, len(ee))
+		for i, e := range ee {
+			items[i] = e.value
+		}
+		v = items
+	}
+
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// budget is a method that applies the response budget to a tool
+// Results (that aren't paged by the tool) that exceed the budget are paged
+// JSON results are paged by elements so that each page is valid JSON; other results are truncated (by lines)
+// The tool gains an optional "cursor" argument that is used to fetch the next page of results
+func (x *Client) budget(tool server.ServerTool) server.ServerTool {
+	if tool.Tool.InputSchema.Properties == nil {
+		tool.Tool.InputSchema.Properties = map[string]any{}
+	}
+	tool.Tool.InputSchema.Properties["cursor"] = map[string]any{
+		"type":        "string",
+		"description": "Cursor returned by a previous (paged) result to fetch the next page of results",
+	}
+
+	handler := tool.Handler
+	tool.Handler = func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		method := rqst.Params.Name
+		logger := x.logger.With("method", method)
+
+		// Element and byte cursors are handled here; the tool is invoked without the cursor
+		// Every page resolves omitted and relative times from the time of the first page
+		c := cursor{now: time.Now()}
+		args := rqst.GetArguments()
+		if s, ok := args["cursor"].(string); ok && s != "" {
+			decoded, err := decodeCursor(s)
+			if err != nil {
+				msg := "unable to parse cursor"
+				return Err(method, msg, err, logger)
+			}
+			c.now = decoded.now
+			if decoded.kind != cursorItems {
+				c = decoded
+				args = maps.Clone(args)
+				delete(args, "cursor")
+				rqst.Params.Arguments = args
+			}
+		}
+		ctx = withNow(ctx, c.now)
+
+		result, err := handler(ctx, rqst)
+		if err != nil || result == nil || result.IsError || len(result.Content) == 0 {
+			return result, err
+		}
+
+		content, ok := result.Content[0].(mcp.TextContent)
+		if !ok || (c.offset == 0 && (x.response.MaxBytes == 0 || len(content.Text) <= x.response.MaxBytes)) {
+			return result, nil
+		}
+
+		var text string
+		var notes []string
+		if ee, object, ok := elements(content.Text); ok && c.kind != cursorBytes {
+			c.kind = cursorElements
+			var n int
+			text, n, err = page(ee, c.offset, x.response.MaxBytes, func(ee []element) (string, error) {
+				return marshalElements(ee, object)
+			})
+			if err != nil {
+				msg := "unable to page result"
+				return Err(method, msg, err, logger)
+			}
+			notes = pageNotes(c, "elements", "", n, len(ee), "")
+		} else {
+			c.kind = cursorBytes
+			var note string
+			text, note, err = truncate(content.Text, c, x.response.MaxBytes)
+			if err != nil {
+				msg := "unable to truncate result"
+				return Err(method, msg, err, logger)
+			}
+			notes = []string{note}
+		}
+
+		logger.Info("Result paged",
+			"bytes", len(content.Text),
+			"offset", c.offset,
+		)
+
+		content.Text = text
+		result.Content[0] = content
+		return withNotes(result, notes), nil
+	}
+
+	return tool
+}
+
+// truncate is a function that returns (at most) maxBytes of text starting at the cursor's offset
+// Text is truncated after the last (complete) line or, if the first line exceeds maxBytes, on a UTF-8 character boundary
+// It returns the truncated text and a note describing the truncation (including a cursor if there's more text)
+// Offsets beyond the end of the text are rejected
+func truncate(text string, c cursor, maxBytes int) (string, string, error) {
+	start := c.offset
+	if start != 0 && start >= len(text) {
+		return "", "", fmt.Errorf("cursor is beyond the end of the result (%d bytes)", len(text))
+	}
+	end := len(text)
+	if maxBytes != 0 && end-start > maxBytes {
+		end = start + maxBytes
+		if i := strings.LastIndexByte(text[start:end], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	note := fmt.Sprintf("truncated: bytes %d-%d of %d", start, end, len(text))
+	if end < len(text) {
+		note += fmt.Sprintf("; pass cursor=%q to fetch the remainder", encodeCursor(c.next(end-start)))
+	}
+
+	return text[start:end], note, nil
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+)
+
+// cursorNote matches the cursor in a page|truncated note
+var cursorNote = regexp.MustCompile(`cursor="([^"]+)"`)
+
+// TestCursor tests encodeCursor and decodeCursor
+func TestCursor(t *testing.T) {
+	now := time.Date(2025, time.June, 13, 10, 0, 0, 500, time.UTC)
+	for _, kind := range []string{cursorItems, cursorElements, cursorBytes} {
+		c, err := decodeCursor(encodeCursor(cursor{kind: kind, offset: 42, now: now}))
+		if err != nil {
+			t.Fatalf("unable to decode cursor: %+v", err)
+		}
+		if c.kind != kind {
+			t.Errorf("got: %s; want: %s", c.kind, kind)
+		}
+		if c.offset != 42 {
+			t.Errorf("%s: got: %d; want: %d", kind, c.offset, 42)
+		}
+		if !c.now.Equal(now) {
+			t.Errorf("%s: got: %s; want: %s", kind, c.now, now)
+		}
+	}
+
+	for _, s := range []string{
+		"!",
+		"eDo0MjoxNzQ5ODA4ODAw",   // x:42:1749808800
+		"aTotMToxNzQ5ODA4ODAw",   // i:-1:1749808800
+		"aTpmb286MTc0OTgwODgwMA", // i:foo:1749808800
+		"aTo0Mg",                 // i:42
+		"aTo0Mjpmb28",            // i:42:foo
+	} {
+		if _, err := decodeCursor(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if _, err := extractCursor(encodeCursor(cursor{kind: cursorBytes, offset: 1, now: now}), now, logger); err == nil {
+		t.Errorf("expected bytes cursor to be rejected")
+	}
+}
+
+// TestPage tests page
+func TestPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	f := func(ii []int) (string, error) {
+		ss := make([]string, len(ii))
+		for i, n := range ii {
+			ss[i] = strconv.Itoa(n)
+		}
+		return strings.Join(ss, ","), nil
+	}
+
+	tests := []struct {
+		offset   int
+		maxBytes int
+		want     string
+		n        int
+	}{
+		{offset: 0, maxBytes: 0, want: "1,2,3,4,5,6,7,8,9", n: 9},
+		{offset: 0, maxBytes: 100, want: "1,2,3,4,5,6,7,8,9", n: 9},
+		{offset: 0, maxBytes: 5, want: "1,2,3", n: 3},
+		{offset: 3, maxBytes: 6, want: "4,5,6", n: 3},
+		{offset: 8, maxBytes: 5, want: "9", n: 1},
+		// Pages contain at least one item
+		{offset: 0, maxBytes: 1, want: "1", n: 1},
+	}
+	for _, test := range tests {
+		got, n, err := page(items, test.offset, test.maxBytes, f)
+		if err != nil {
+			t.Fatalf("unable to page: %+v", err)
+		}
+		if got != test.want || n != test.n {
+			t.Errorf("page(%d, %d): got: %q, %d; want: %q, %d", test.offset, test.maxBytes, got, n, test.want, test.n)
+		}
+	}
+
+	// Offsets beyond the last item are rejected
+	if _, _, err := page(items, len(items), 5, f); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestTruncate tests truncate
+func TestTruncate(t *testing.T) {
+	text := "héllo, world"
+
+	// "é" is 2 bytes so truncating at 2 bytes must not split it
+	got, n, err := truncate(text, cursor{kind: cursorBytes, now: time.Now()}, 2)
+	if err != nil {
+		t.Fatalf("unable to truncate: %+v", err)
+	}
+	if got != "h" {
+		t.Errorf("got: %q; want: %q", got, "h")
+	}
+
+	m := cursorNote.FindStringSubmatch(n)
+	if m == nil {
+		t.Fatalf("expected cursor: %s", n)
+	}
+	c, err := decodeCursor(m[1])
+	if err != nil {
+		t.Fatalf("unable to decode cursor: %+v", err)
+	}
+
+	got, n, err = truncate(text, c, 100)
+	if err != nil {
+		t.Fatalf("unable to truncate: %+v", err)
+	}
+	if got != "éllo, world" {
+		t.Errorf("got: %q; want: %q", got, "éllo, world")
+	}
+	if cursorNote.MatchString(n) {
+		t.Errorf("unexpected cursor: %s", n)
+	}
+
+	// Offsets beyond the end of the text are rejected
+	if _, _, err := truncate(text, c.next(100), 100); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestTruncateLines tests that truncate truncates after the last complete line
+func TestTruncateLines(t *testing.T) {
+	text := "name,value\nfoo,1\nbar,2\n"
+
+	got, _, err := truncate(text, cursor{kind: cursorBytes, now: time.Now()}, 20)
+	if err != nil {
+		t.Fatalf("unable to truncate: %+v", err)
+	}
+	if want := "name,value\nfoo,1\n"; got != want {
+		t.Errorf("got: %q; want: %q", got, want)
+	}
+}
+
+// TestBudgetTools tests that only tools whose results are lists are subject to the response budget
+func TestBudgetTools(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	c := NewClient(nil, logger, WithAdmin(true), WithResponse(config.Response{MaxBytes: 64}))
+	for _, tool := range c.Tools() {
+		_, got := tool.Tool.InputSchema.Properties["cursor"]
+		if want := slices.Contains(paged, tool.Tool.Name); got != want {
+			t.Errorf("%s: got cursor: %t; want: %t", tool.Tool.Name, got, want)
+		}
+	}
+}
+
+// TestBudgetElements tests that JSON results that exceed the budget are paged by elements and each page is valid JSON
+func TestBudgetElements(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			name: "array",
+			text: `["up","go_goroutines","process_cpu_seconds_total","prometheus_http_requests_total","scrape_duration_seconds"]`,
+		},
+		{
+			name: "object",
+			text: `{"activeTargets":[{"job":"node","health":"up"},{"job":"prometheus","health":"up"},{"job":"blackbox","health":"down"}],"droppedTargets":[{"job":"node"}],"status":"ok"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewClient(nil, logger, WithResponse(config.Response{MaxBytes: 64}))
+			tool := c.budget(server.ServerTool{
+				Tool: mcp.NewTool("test"),
+				Handler: func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					return mcp.NewToolResultText(test.text), nil
+				},
+			})
+
+			// Pages' elements are merged (by field) to reconstruct the result
+			var items []any
+			fields := map[string]any{}
+			args := map[string]any{}
+			pages := 0
+			for range 10 {
+				rqst := mcp.CallToolRequest{
+					Params: mcp.CallToolParams{
+						Name:      "test",
+						Arguments: args,
+					},
+				}
+				resp, err := tool.Handler(context.Background(), rqst)
+				if err != nil {
+					t.Fatalf("unable to invoke handler: %+v", err)
+				}
+				pages++
+
+				var v any
+				if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &v); err != nil {
+					t.Fatalf("expected valid JSON: %+v", err)
+				}
+				switch v := v.(type) {
+				case []any:
+					items = append(items, v...)
+				case map[string]any:
+					for key, value := range v {
+						if a, ok := value.([]any); ok {
+							b, _ := fields[key].([]any)
+							value = append(b, a...)
+						}
+						fields[key] = value
+					}
+				}
+
+				m := cursorNote.FindStringSubmatch(note(resp, "page:"))
+				if m == nil {
+					break
+				}
+				args = map[string]any{"cursor": m[1]}
+			}
+
+			if pages < 2 {
+				t.Errorf("got: %d pages; want: at least 2", pages)
+			}
+
+			var got any = fields
+			if items != nil {
+				got = items
+			}
+			var want any
+			if err := json.Unmarshal([]byte(test.text), &want); err != nil {
+				t.Fatalf("unable to unmarshal: %+v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: %+v; want: %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestBudget tests that results that exceed the budget are truncated and may be fetched using a cursor
+func TestBudget(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	text := strings.Repeat("0123456789", 10)
+
+	c := NewClient(nil, logger, WithResponse(config.Response{MaxBytes: 64}))
+	tool := c.budget(server.ServerTool{
+		Tool: mcp.NewTool("test"),
+		Handler: func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if _, ok := rqst.GetArguments()["cursor"]; ok {
+				t.Errorf("expected bytes cursor to be removed")
+			}
+			return mcp.NewToolResultText(text), nil
+		},
+	})
+
+	if _, ok := tool.Tool.InputSchema.Properties["cursor"]; !ok {
+		t.Errorf("expected 'cursor' property")
+	}
+
+	got := ""
+	args := map[string]any{}
+	for range 3 {
+		rqst := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "test",
+				Arguments: args,
+			},
+		}
+		resp, err := tool.Handler(context.Background(), rqst)
+		if err != nil {
+			t.Fatalf("unable to invoke handler: %+v", err)
+		}
+
+		got += resp.Content[0].(mcp.TextContent).Text
+
+		m := cursorNote.FindStringSubmatch(note(resp, "truncated:"))
+		if m == nil {
+			break
+		}
+		args = map[string]any{"cursor": m[1]}
+	}
+
+	if got != text {
+		t.Errorf("got: %q; want: %q", got, text)
+	}
+}
+
+// TestQueryPaging tests that Query returns the top series (by value) and a cursor for the remainder
+func TestQueryPaging(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Every page must be evaluated at the same time
+	times := map[string]struct{}{}
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unable to parse form: %+v", err)
+		}
+		times[r.Form.Get("time")] = struct{}{}
+
+		resp := `{"data":{"resultType":"vector","result":[` +
+			`{"metric":{"instance":"a"},"value":[1749772800,"1"]},` +
+			`{"metric":{"instance":"b"},"value":[1749772800,"3"]},` +
+			`{"metric":{"instance":"c"},"value":[1749772800,"NaN"]},` +
+			`{"metric":{"instance":"d"},"value":[1749772800,"2"]}` +
+			`]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	// Each sample is ~60 bytes (as JSON) so pages contain 2 series
+	c := NewClient(apiClient, logger, WithResponse(config.Response{MaxBytes: 150}))
+
+	got := []string{}
+	args := map[string]any{"query": "up"}
+	for range 4 {
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name:      "Query",
+				Arguments: args,
+			},
+		}
+		resp, err := c.Query(context.Background(), rqst)
+		if err != nil {
+			t.Fatalf("unable to invoke Query method: %+v", err)
+		}
+
+		t.Logf("Response: %+v", resp)
+
+		var vector model.Vector
+		if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &vector); err != nil {
+			t.Fatalf("unable to unmarshal vector: %+v", err)
+		}
+		for _, sample := range vector {
+			got = append(got, string(sample.Metric["instance"]))
+		}
+
+		n := note(resp, "page:")
+		if n == "" {
+			t.Fatalf("expected page note")
+		}
+		m := cursorNote.FindStringSubmatch(n)
+		if m == nil {
+			break
+		}
+		args = map[string]any{"query": "up", "cursor": m[1]}
+	}
+
+	// Sorted by value (descending) with NaN last
+	want := "b,d,a,c"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got: %s; want: %s", strings.Join(got, ","), want)
+	}
+	if len(times) != 1 {
+		t.Errorf("got: %d times; want: 1", len(times))
+	}
+
+	// Cursors beyond the end of the results are rejected
+	rqst := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "Query",
+			Arguments: map[string]any{
+				"query":  "up",
+				"cursor": encodeCursor(cursor{kind: cursorItems, offset: 4, now: time.Now()}),
+			},
+		},
+	}
+	if _, err := c.Query(context.Background(), rqst); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -3,31 +3,80 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/DazWilkin/prometheus-mcp-server/config"
 	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+	"github.com/DazWilkin/prometheus-mcp-server/render"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 )
 
 // Client is a type that represents a Prometheus client
 type Client struct {
-	v1api  v1.API
+	// apiClient is used for Prometheus HTTP API methods that aren't implemented by v1.API
+	apiClient api.Client
+	v1api     v1.API
+	// admin enables the TSDB Admin API methods (snapshot, delete_series, clean_tombstones)
+	admin bool
+	// guardrails are the budgets that protect Prometheus from expensive queries
+	guardrails config.Guardrails
+	// queryRange configures range queries e.g. the target number of points when step is omitted
+	queryRange config.QueryRange
+	// response is the response budget; larger results are paged
+	response config.Response
+	// datasource is the name of the client's datasource
+	datasource string
+	// tenant is the datasource's default tenant (if any)
+	tenant string
+	// key is used to sign delete_series dry-run confirmations
+	key    []byte
 	logger *slog.Logger
 }
 
+// ClientOption is a type that represents an optional configuration of Client
+type ClientOption func(*Client)
+
+// WithAdmin is a function that enables|disables the TSDB Admin API methods
+func WithAdmin(admin bool) ClientOption {
+	return func(x *Client) {
+		x.admin = admin
+	}
+}
+
+// WithDatasource is a function that identifies the client's datasource and its default tenant
+func WithDatasource(name, tenant string) ClientOption {
+	return func(x *Client) {
+		x.datasource = name
+		x.tenant = tenant
+	}
+}
+
 // NewClient is a function that creates a new Client
-func NewClient(apiClient api.Client, logger *slog.Logger) *Client {
+func NewClient(apiClient api.Client, logger *slog.Logger, opts ...ClientOption) *Client {
 	logger.Info("Creating new Prometheus client")
 	v1api := v1.NewAPI(apiClient)
-	return &Client{
-		v1api:  v1api,
-		logger: logger,
+	x := &Client{
+		apiClient: apiClient,
+		v1api:     v1api,
+		key:       newKey(),
+		logger:    logger,
+	}
+	for _, opt := range opts {
+		opt(x)
 	}
+	return x
 }
 
 // Err is a function that combines logging, metrics and returning errors
@@ -62,6 +111,10 @@ func (x *Client) Tools() []server.ServerTool {
 			Tool: mcp.NewTool(
 				"alerts",
 				mcp.WithDescription("Prometheus Alerts"),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
 			),
 			Handler: x.Alerts,
 		},
@@ -75,15 +128,98 @@ func (x *Client) Tools() []server.ServerTool {
 				),
 				mcp.WithString("start",
 					mcp.Required(),
-					mcp.Description("Start timestamp (RFC-3339)"),
+					mcp.Description("Start timestamp (RFC-3339, Unix epoch or relative e.g. now-1h, -30m, now/d) or a duration before end (e.g. 1h)"),
 				),
 				mcp.WithString("end",
 					mcp.Required(),
-					mcp.Description("End timestamp (RFC-3339)"),
+					mcp.Description("End timestamp (RFC-3339, Unix epoch or relative e.g. now, now-5m, now/d)"),
 				),
 			),
 			Handler: x.Exemplars,
 		},
+		{
+			Tool: mcp.NewTool(
+				"format_query",
+				mcp.WithDescription("Format (pretty-print) a PromQL expression. Formatted locally (without querying Prometheus) and, if the expression can't be parsed locally, by Prometheus"),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Prometheus expression query string"),
+				),
+			),
+			Handler: x.FormatQuery,
+		},
+		{
+			Tool: mcp.NewTool(
+				"label_values",
+				mcp.WithDescription("Prometheus Label Values"),
+				mcp.WithString("label",
+					mcp.Required(),
+					mcp.Description("Label name for which values are returned"),
+				),
+				// https://prometheus.io/docs/prometheus/latest/querying/api/#querying-label-values
+				// The query string parameter is "match[]"
+				mcp.WithArray("match[]",
+					mcp.Items(map[string]any{"type": "string"}),
+					mcp.Description("Repeated series selector argument that selects the series from which to read the label values"),
+				),
+				mcp.WithString("start",
+					mcp.Description("Start timestamp (RFC-3339, Unix epoch or relative e.g. now-1h, -30m, now/d)"),
+				),
+				mcp.WithString("end",
+					mcp.Description("End timestamp (RFC-3339, Unix epoch or relative e.g. now, now-5m, now/d)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of returned label values"),
+				),
+			),
+			Handler: x.LabelValues,
+		},
+		{
+			Tool: mcp.NewTool(
+				"labels",
+				mcp.WithDescription("Prometheus Label Names"),
+				// https://prometheus.io/docs/prometheus/latest/querying/api/#getting-label-names
+				// The query string parameter is "match[]"
+				mcp.WithArray("match[]",
+					mcp.Items(map[string]any{"type": "string"}),
+					mcp.Description("Repeated series selector argument that selects the series from which to read the label names"),
+				),
+				mcp.WithString("start",
+					mcp.Description("Start timestamp (RFC-3339, Unix epoch or relative e.g. now-1h, -30m, now/d)"),
+				),
+				mcp.WithString("end",
+					mcp.Description("End timestamp (RFC-3339, Unix epoch or relative e.g. now, now-5m, now/d)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of returned label names"),
+				),
+			),
+			Handler: x.Labels,
+		},
+		{
+			Tool: mcp.NewTool(
+				"lint_query",
+				mcp.WithDescription("Lint a PromQL expression for common mistakes (e.g. rate() on gauges, histogram_quantile() without 'le', ranges shorter than the scrape interval). Uses metric metadata and target scrape intervals from Prometheus. Returns warnings with suggested fixes"),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Prometheus expression query string"),
+				),
+			),
+			Handler: x.LintQuery,
+		},
+		{
+			Tool: mcp.NewTool(
+				"metadata",
+				mcp.WithDescription("Prometheus Metric Metadata (type, help and unit)"),
+				mcp.WithString("metric",
+					mcp.Description("Metric name for which metadata is returned; all metrics if omitted"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of returned metrics"),
+				),
+			),
+			Handler: x.Metadata,
+		},
 		{
 			Tool: mcp.NewTool(
 				"metrics",
@@ -100,7 +236,7 @@ func (x *Client) Tools() []server.ServerTool {
 					mcp.Description("Prometheus expression query string"),
 				),
 				mcp.WithString("time",
-					mcp.Description("Evaluation timestamp (RFC-3339)"),
+					mcp.Description("Evaluation timestamp (RFC-3339, Unix epoch or relative e.g. now-5m, now/h). Defaults to now"),
 				),
 				mcp.WithString("timeout",
 					mcp.Description("Evaluation timeout"),
@@ -108,6 +244,10 @@ func (x *Client) Tools() []server.ServerTool {
 				mcp.WithNumber("limit",
 					mcp.Description("Maximum number of returned series"),
 				),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
 			),
 			Handler: x.Query,
 		},
@@ -121,15 +261,14 @@ func (x *Client) Tools() []server.ServerTool {
 				),
 				mcp.WithString("start",
 					mcp.Required(),
-					mcp.Description("Start timestamp (RFC-3339)"),
+					mcp.Description("Start timestamp (RFC-3339, Unix epoch or relative e.g. now-1h, -30m, now/d) or a duration before end (e.g. 1h)"),
 				),
 				mcp.WithString("end",
 					mcp.Required(),
-					mcp.Description("End timestamp (RFC-3339)"),
+					mcp.Description("End timestamp (RFC-3339, Unix epoch or relative e.g. now, now-5m, now/d)"),
 				),
 				mcp.WithString("step",
-					mcp.Required(),
-					mcp.Description("Query resolution step width in duration format"),
+					mcp.Description("Query resolution step width in duration format. If omitted, it's computed from the range and aligned to the scrape interval"),
 				),
 				mcp.WithString("timeout",
 					mcp.Description("Evaluation timeout"),
@@ -137,6 +276,13 @@ func (x *Client) Tools() []server.ServerTool {
 				mcp.WithNumber("limit",
 					mcp.Description("Maximum number of returned series"),
 				),
+				mcp.WithBoolean("summary",
+					mcp.Description("If true, return per-series statistics (count, NaN and gap counts, min, max, avg, p50, p95, first, last, slope per second) and a sparkline instead of samples"),
+				),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
 			),
 			Handler: x.QueryRange,
 		},
@@ -161,18 +307,54 @@ func (x *Client) Tools() []server.ServerTool {
 				),
 				mcp.WithString("start",
 					mcp.Required(),
-					mcp.Description("Start timestamp (RFC-3339)"),
+					mcp.Description("Start timestamp (RFC-3339, Unix epoch or relative e.g. now-1h, -30m, now/d) or a duration before end (e.g. 1h)"),
 				),
 				mcp.WithString("end",
 					mcp.Required(),
-					mcp.Description("End timestamp (RFC-3339)"),
+					mcp.Description("End timestamp (RFC-3339, Unix epoch or relative e.g. now, now-5m, now/d)"),
 				),
 				mcp.WithNumber("limit",
 					mcp.Description("Maximum number of returned series"),
 				),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
 			),
 			Handler: x.Series,
 		},
+		{
+			Tool: mcp.NewTool(
+				"status_buildinfo",
+				mcp.WithDescription("Prometheus Status: Build Information"),
+			),
+			Handler: x.StatusBuildinfo,
+		},
+		{
+			Tool: mcp.NewTool(
+				"status_config",
+				mcp.WithDescription("Prometheus Status: Configuration"),
+				mcp.WithArray("sections",
+					mcp.Items(map[string]any{"type": "string"}),
+					mcp.Description("Top-level configuration sections (e.g. global, scrape_configs, rule_files, remote_write) to return parsed; omitted (empty) sections are returned as null and the whole configuration is returned as YAML if no sections are requested"),
+				),
+			),
+			Handler: x.StatusConfig,
+		},
+		{
+			Tool: mcp.NewTool(
+				"status_flags",
+				mcp.WithDescription("Prometheus Status: Flags"),
+			),
+			Handler: x.StatusFlags,
+		},
+		{
+			Tool: mcp.NewTool(
+				"status_runtimeinfo",
+				mcp.WithDescription("Prometheus Status: Runtime Information"),
+			),
+			Handler: x.StatusRuntimeinfo,
+		},
 		{
 			Tool: mcp.NewTool(
 				"status_tsdb",
@@ -180,14 +362,66 @@ func (x *Client) Tools() []server.ServerTool {
 			),
 			Handler: x.StatusTSDB,
 		},
+		{
+			Tool: mcp.NewTool(
+				"status_walreplay",
+				mcp.WithDescription("Prometheus Status: WAL Replay"),
+			),
+			Handler: x.StatusWalReplay,
+		},
 		{
 			Tool: mcp.NewTool(
 				"targets",
 				mcp.WithDescription("Prometheus Targets"),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
 			),
 			Handler: x.Targets,
 		},
+		{
+			Tool: mcp.NewTool(
+				"targets_metadata",
+				mcp.WithDescription("Prometheus Targets Metric Metadata (type, help and unit)"),
+				mcp.WithString("match_target",
+					mcp.Description("Label selector that matches targets by their label sets; all targets if omitted"),
+				),
+				mcp.WithString("metric",
+					mcp.Description("Metric name for which metadata is returned; all metrics if omitted"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of returned targets"),
+				),
+			),
+			Handler: x.TargetsMetadata,
+		},
+		{
+			Tool: mcp.NewTool(
+				"validate_query",
+				mcp.WithDescription("Validate a PromQL expression locally (without querying Prometheus). Returns a summary of the expression (type, metrics, selectors, functions, aggregations) or position-annotated syntax errors"),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Prometheus expression query string"),
+				),
+			),
+			Handler: x.ValidateQuery,
+		},
+	}
+
+	// TSDB Admin API methods are only published when explicitly enabled
+	if x.admin {
+		logger.Info("TSDB Admin API enabled")
+		tools = append(tools, x.adminTools()...)
 	}
+
+	// Results (that are lists) are paged to fit the response budget
+	for i, tool := range tools {
+		if slices.Contains(paged, tool.Tool.Name) {
+			tools[i] = x.budget(tool)
+		}
+	}
+
 	return tools
 }
 
@@ -236,6 +470,17 @@ func (x *Client) Alerts(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Cal
 		"tool": method,
 	}).Inc()
 
+	// Tool provides arguments; retrieve these
+	// optional: output
+	args := rqst.GetArguments()
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
 	// Invoke Prometheus Alerts method
 	result, err := x.v1api.Alerts(ctx)
 	if err != nil {
@@ -247,13 +492,15 @@ func (x *Client) Alerts(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Cal
 		"alerts", len(result.Alerts),
 	)
 
-	b, err := json.Marshal(result)
+	text, err := output(format, result, func() *render.Result {
+		return render.Alerts(result)
+	})
 	if err != nil {
-		msg := "unable to marshal alerts"
+		msg := "unable to render alerts"
 		return Err(method, msg, err, logger)
 	}
 
-	return mcp.NewToolResultText(string(b)), nil
+	return mcp.NewToolResultText(text), nil
 }
 
 // Exemplars is a method that queries Prometheus for a list of Exemplars
@@ -273,14 +520,16 @@ func (x *Client) Exemplars(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.
 	args := rqst.GetArguments()
 	// Required
 	query := args["query"].(string)
-	startTime, err := extractTimestamp(args["start"], logger)
+	// Relative times are resolved from the time of the (first) page
+	now := nowFrom(ctx)
+	endTime, err := extractTimestamp(args["end"], now, logger)
 	if err != nil {
-		msg := "unable to extract 'start' parameter"
+		msg := "unable to extract 'end' parameter"
 		return Err(method, msg, err, logger)
 	}
-	endTime, err := extractTimestamp(args["end"], logger)
+	startTime, err := extractStart(args["start"], endTime, now, logger)
 	if err != nil {
-		msg := "unable to extract 'end' parameter"
+		msg := "unable to extract 'start' parameter"
 		return Err(method, msg, err, logger)
 	}
 
@@ -300,12 +549,14 @@ func (x *Client) Exemplars(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.
 		return Err(method, msg, err, logger)
 	}
 
-	return mcp.NewToolResultText(string(b)), nil
+	// Echo the resolved times
+	return withNotes(mcp.NewToolResultText(string(b)), resolvedNotes(startTime, endTime)), nil
 }
 
-// Metrics is a method that queries Prometheus for a list of Metrics
-func (x *Client) Metrics(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "Metrics"
+// FormatQuery is a method that formats (pretty-prints) a PromQL expression
+// The expression is formatted locally and, only if that fails, by Prometheus
+func (x *Client) FormatQuery(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "FormatQuery"
 	logger := x.logger.With("method", method)
 	logger.Debug("Entered")
 	defer logger.Debug("Exited")
@@ -315,41 +566,79 @@ func (x *Client) Metrics(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Ca
 		"tool": method,
 	}).Inc()
 
-	// Tool provides no arguments (neither required nor optional)
-	// Define parameters
-	label := "__name__"
-	var matches []string
-	startTime := time.Time{}
-	endTime := time.Time{}
+	// Tool provides arguments; retrieve these
+	// required: query
+	args := rqst.GetArguments()
 
-	// Invoke Prometheus LabelValues method
-	labelvalues, warnings, err := x.v1api.LabelValues(ctx, label, matches, startTime, endTime)
-	if err != nil {
-		msg := "unable to retrieve metrics"
+	// Required
+	query, ok := args["query"].(string)
+	if !ok {
+		msg := "unable to extract 'query' parameter"
+		return Err(method, msg, nil, logger)
+	}
+
+	// Format the query locally
+	formatted, err := promql.Format(query)
+	if err == nil {
+		logger.Info("Query formatted locally")
+		return mcp.NewToolResultText(formatted), nil
+	}
+
+	// The local parser may not support everything that Prometheus does (e.g. experimental functions)
+	// So fallback to Prometheus' format_query method
+	logger.Info("Unable to format query locally; trying Prometheus", "err", err)
+	formatted, rerr := x.formatQuery(ctx, query)
+	if rerr != nil {
+		logger.Info("Unable to format query using Prometheus", "err", rerr)
+
+		// Report the (position-annotated) local error since the remote error is likely the same or connectivity
+		msg := fmt.Sprintf("unable to format PromQL query: %s", promql.Validate(query).Err())
 		return Err(method, msg, err, logger)
 	}
 
-	logger.Info("Metrics retrieved",
-		"metrics", len(labelvalues),
-	)
+	logger.Info("Query formatted by Prometheus")
+	return mcp.NewToolResultText(formatted), nil
+}
 
-	// If there are warnings, log them
-	if len(warnings) != 0 {
-		logger.Info("Warnings", "warnings", warnings)
+// formatQuery is a method that formats a PromQL expression using Prometheus' format_query method
+// This method isn't implemented by v1.API
+// https://prometheus.io/docs/prometheus/latest/querying/api/#formatting-query-expressions
+func (x *Client) formatQuery(ctx context.Context, query string) (string, error) {
+	u := x.apiClient.URL("/api/v1/format_query", nil)
+	q := u.Query()
+	q.Set("query", query)
+	u.RawQuery = q.Encode()
+
+	rqst, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
 	}
 
-	b, err := json.Marshal(labelvalues)
+	resp, body, err := x.apiClient.Do(ctx, rqst)
 	if err != nil {
-		msg := "unable to marshal metrics"
-		return Err(method, msg, err, logger)
+		return "", err
 	}
 
-	return mcp.NewToolResultText(string(b)), nil
+	// Prometheus API responses are wrapped in an envelope
+	result := struct {
+		Status string `json:"status"`
+		Data   string `json:"data"`
+		Error  string `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unable to unmarshal response (%d): %w", resp.StatusCode, err)
+	}
+
+	if result.Status != "success" {
+		return "", fmt.Errorf("prometheus responded %d: %s", resp.StatusCode, result.Error)
+	}
+
+	return result.Data, nil
 }
 
-// Query is a method that queries Prometheus with PromQL and returns an instant query
-func (x *Client) Query(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "Query"
+// LabelValues is a method that queries Prometheus for a list of values of a Label
+func (x *Client) LabelValues(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "LabelValues"
 	logger := x.logger.With("method", method)
 	logger.Debug("Entered")
 	defer logger.Debug("Exited")
@@ -360,53 +649,78 @@ func (x *Client) Query(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Call
 	}).Inc()
 
 	// Tool provides arguments; retrieve these
-	// required: query
-	// optional: time, timeout, limit
+	// required: label
+	// optional: match[], start, end, limit
 	args := rqst.GetArguments()
 
 	// Required
-	query := args["query"].(string)
+	label, ok := args["label"].(string)
+	if !ok || label == "" {
+		msg := "unable to extract 'label' parameter"
+		return Err(method, msg, nil, logger)
+	}
 
 	// Optional
-	// Required by Prometheus API method
-	ts, err := extractTimestamp(args["time"], logger)
+	var matches []string
+	if m, ok := args["match[]"]; ok {
+		var err error
+		matches, err = extractMatches(m, logger)
+		if err != nil {
+			msg := "unable to extract repeated 'match[]' parameters"
+			return Err(method, msg, err, logger)
+		}
+	}
+
+	// Relative times are resolved from the time of the (first) page
+	now := nowFrom(ctx)
+	startTime, err := extractTimestamp(args["start"], now, logger)
 	if err != nil {
-		msg := "unable to extract 'time' parameter"
+		msg := "unable to extract 'start' parameter"
 		return Err(method, msg, err, logger)
 	}
 
-	// Optional
-	// Optional for Prometheus API method: timeout,limit
+	endTime, err := extractTimestamp(args["end"], now, logger)
+	if err != nil {
+		msg := "unable to extract 'end' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional for Prometheus API method: limit
 	opts, err := extractOptions(args, logger)
 	if err != nil {
 		msg := "unable to extract optional arguments"
 		return Err(method, msg, err, logger)
 	}
 
-	// Invoke Prometheus Query method
-	value, warnings, err := x.v1api.Query(ctx, query, ts, opts...)
+	// Invoke Prometheus LabelValues method
+	labelvalues, warnings, err := x.v1api.LabelValues(ctx, label, matches, startTime, endTime, opts...)
 	if err != nil {
-		msg := "unable to retrieve query results"
+		msg := "unable to retrieve label values"
 		return Err(method, msg, err, logger)
 	}
 
+	logger.Info("Label values retrieved",
+		"label", label,
+		"values", len(labelvalues),
+	)
+
 	// If there are warnings, log them
 	if len(warnings) != 0 {
 		logger.Info("Warnings", "warnings", warnings)
 	}
 
-	b, err := json.Marshal(value)
+	b, err := json.Marshal(labelvalues)
 	if err != nil {
-		msg := "unable to marshal query results"
+		msg := "unable to marshal label values"
 		return Err(method, msg, err, logger)
 	}
 
 	return mcp.NewToolResultText(string(b)), nil
 }
 
-// QueryRange is a method queries Promethues with PromQL and returns a range query
-func (x *Client) QueryRange(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "QueryRange"
+// Labels is a method that queries Prometheus for a list of Label names
+func (x *Client) Labels(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Labels"
 	logger := x.logger.With("method", method)
 	logger.Debug("Entered")
 	defer logger.Debug("Exited")
@@ -416,68 +730,70 @@ func (x *Client) QueryRange(ctx context.Context, rqst mcp.CallToolRequest) (*mcp
 		"tool": method,
 	}).Inc()
 
+	// Tool provides arguments; retrieve these
+	// optional: match[], start, end, limit
 	args := rqst.GetArguments()
 
-	// Required
-	query := args["query"].(string)
+	// Optional
+	var matches []string
+	if m, ok := args["match[]"]; ok {
+		var err error
+		matches, err = extractMatches(m, logger)
+		if err != nil {
+			msg := "unable to extract repeated 'match[]' parameters"
+			return Err(method, msg, err, logger)
+		}
+	}
 
-	start, err := extractTimestamp(args["start"], logger)
+	// Relative times are resolved from the time of the (first) page
+	now := nowFrom(ctx)
+	startTime, err := extractTimestamp(args["start"], now, logger)
 	if err != nil {
 		msg := "unable to extract 'start' parameter"
 		return Err(method, msg, err, logger)
 	}
 
-	end, err := extractTimestamp(args["end"], logger)
+	endTime, err := extractTimestamp(args["end"], now, logger)
 	if err != nil {
 		msg := "unable to extract 'end' parameter"
 		return Err(method, msg, err, logger)
 	}
 
-	step, err := extractDuration(args["step"], logger)
-	if err != nil {
-		msg := "unable to extract 'step' parameter"
-		return Err(method, msg, err, logger)
-	}
-
-	// Create Range
-	r := v1.Range{
-		Start: start,
-		End:   end,
-		Step:  step,
-	}
-
-	// Optional
-	// Optional for Prometheus API method: timeout,limit
+	// Optional for Prometheus API method: limit
 	opts, err := extractOptions(args, logger)
 	if err != nil {
 		msg := "unable to extract optional arguments"
 		return Err(method, msg, err, logger)
 	}
 
-	// Invoke Prometheus QueryRange method
-	value, warnings, err := x.v1api.QueryRange(ctx, query, r, opts...)
+	// Invoke Prometheus LabelNames method
+	labelnames, warnings, err := x.v1api.LabelNames(ctx, matches, startTime, endTime, opts...)
 	if err != nil {
-		msg := "unable to query results"
+		msg := "unable to retrieve label names"
 		return Err(method, msg, err, logger)
 	}
 
+	logger.Info("Label names retrieved",
+		"labels", len(labelnames),
+	)
+
 	// If there are warnings, log them
 	if len(warnings) != 0 {
 		logger.Info("Warnings", "warnings", warnings)
 	}
 
-	b, err := json.Marshal(value)
+	b, err := json.Marshal(labelnames)
 	if err != nil {
-		msg := "unable to marshal query results"
+		msg := "unable to marshal label names"
 		return Err(method, msg, err, logger)
 	}
 
 	return mcp.NewToolResultText(string(b)), nil
 }
 
-// Rules is a method that queries Prometheus for a list of Rules
-func (x *Client) Rules(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "Rules"
+// LintQuery is a method that lints a PromQL expression for common mistakes
+func (x *Client) LintQuery(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "LintQuery"
 	logger := x.logger.With("method", method)
 	logger.Debug("Entered")
 	defer logger.Debug("Exited")
@@ -487,59 +803,520 @@ func (x *Client) Rules(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Call
 		"tool": method,
 	}).Inc()
 
-	// Invoke Prometheus Rules method
-	result, err := x.v1api.Rules(ctx)
-	if err != nil {
-		msg := "unable to retrieve rules"
-		return Err(method, msg, err, logger)
+	// Tool provides arguments; retrieve these
+	// required: query
+	args := rqst.GetArguments()
+
+	// Required
+	query, ok := args["query"].(string)
+	if !ok {
+		msg := "unable to extract 'query' parameter"
+		return Err(method, msg, nil, logger)
 	}
 
-	logger.Info("Rules retrieved",
-		"rules", len(result.Groups),
+	// Invalid queries can't be linted
+	// An invalid query is a successful lint that reports the syntax errors
+	info := promql.LintInfo{}
+	if v := promql.Validate(query); v.Valid {
+		info = x.lintInfo(ctx, v.Metrics, logger)
+	}
+
+	l := promql.LintQuery(query, info)
+
+	logger.Info("Query linted",
+		"valid", l.Valid,
+		"warnings", len(l.Warnings),
 	)
 
-	b, err := json.Marshal(result)
+	b, err := json.Marshal(l)
 	if err != nil {
-		msg := "unable to marshal targets"
+		msg := "unable to marshal lint"
 		return Err(method, msg, err, logger)
 	}
 
 	return mcp.NewToolResultText(string(b)), nil
 }
 
-// Series is a method that queries Prometheus for a list of Series
-func (x *Client) Series(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "Series"
-	logger := x.logger.With("method", method)
-	logger.Debug("Entered")
-	defer logger.Debug("Exited")
+// lintInfo is a method that retrieves the metric metadata and target scrape intervals used to lint queries
+// Linting uses naming conventions when this information is unavailable so errors are logged but ignored
+func (x *Client) lintInfo(ctx context.Context, metrics []string, logger *slog.Logger) promql.LintInfo {
+	info := promql.LintInfo{
+		Types:           map[string]string{},
+		ScrapeIntervals: map[string]time.Duration{},
+	}
 
-	// Increment Prometheus total metric
-	totalx.With(prometheus.Labels{
-		"tool": method,
-	}).Inc()
+	// Metadata is keyed by metric family
+	// Histograms and summaries (e.g. foo_bucket) and OpenMetrics counters (e.g. foo_total) are keyed by the base name
+	names := []string{}
+	for _, metric := range metrics {
+		names = append(names, metric)
+		for _, suffix := range []string{"_bucket", "_count", "_sum", "_total"} {
+			if base, ok := strings.CutSuffix(metric, suffix); ok {
+				names = append(names, base)
+			}
+		}
+	}
 
-	args := rqst.GetArguments()
+	for _, name := range names {
+		metadata, err := x.v1api.Metadata(ctx, name, "")
+		if err != nil {
+			logger.Info("unable to retrieve metadata", "metric", name, "err", err)
+			continue
+		}
+		for metric, mm := range metadata {
+			if len(mm) != 0 {
+				info.Types[metric] = string(mm[0].Type)
+			}
+		}
+	}
 
-	// Required
+	intervals, err := x.scrapeIntervals(ctx)
+	if err != nil {
+		logger.Info("unable to retrieve targets", "err", err)
+		return info
+	}
+	info.ScrapeIntervals = intervals
+
+	return info
+}
+
+// scrapeIntervals is a method that retrieves the scrape interval of each job from Prometheus' active targets
+func (x *Client) scrapeIntervals(ctx context.Context) (map[string]time.Duration, error) {
+	targets, err := x.v1api.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := map[string]time.Duration{}
+	for _, target := range targets.Active {
+		job := string(target.Labels["job"])
+		interval, err := model.ParseDuration(target.DiscoveredLabels["__scrape_interval__"])
+		if job == "" || err != nil {
+			continue
+		}
+		intervals[job] = time.Duration(interval)
+	}
+
+	return intervals, nil
+}
+
+// Metadata is a method that queries Prometheus for the Metadata (type, help, unit) of Metrics
+func (x *Client) Metadata(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Metadata"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// optional: metric, limit
+	args := rqst.GetArguments()
+
+	// Optional
+	// Prometheus API method uses "" to represent all metrics
+	metric, _ := args["metric"].(string)
+
+	// Optional
+	// Prometheus API method uses "" to represent no limit
+	limit, err := extractLimit(args["limit"], logger)
+	if err != nil {
+		msg := "unable to extract 'limit' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus Metadata method
+	metadata, err := x.v1api.Metadata(ctx, metric, formatLimit(limit))
+	if err != nil {
+		msg := "unable to retrieve metadata"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Metadata retrieved",
+		"metrics", len(metadata),
+	)
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		msg := "unable to marshal metadata"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// Metrics is a method that queries Prometheus for a list of Metrics
+func (x *Client) Metrics(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Metrics"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides no arguments (neither required nor optional)
+	// Define parameters
+	label := "__name__"
+	var matches []string
+	startTime := time.Time{}
+	endTime := time.Time{}
+
+	// Invoke Prometheus LabelValues method
+	labelvalues, warnings, err := x.v1api.LabelValues(ctx, label, matches, startTime, endTime)
+	if err != nil {
+		msg := "unable to retrieve metrics"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Metrics retrieved",
+		"metrics", len(labelvalues),
+	)
+
+	// If there are warnings, log them
+	if len(warnings) != 0 {
+		logger.Info("Warnings", "warnings", warnings)
+	}
+
+	b, err := json.Marshal(labelvalues)
+	if err != nil {
+		msg := "unable to marshal metrics"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// Query is a method that queries Prometheus with PromQL and returns an instant query
+func (x *Client) Query(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Query"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// required: query
+	// optional: time, timeout, limit
+	args := rqst.GetArguments()
+
+	// Required
+	query := args["query"].(string)
+
+	// Validate the query locally before sending it to Prometheus
+	if v := promql.Validate(query); !v.Valid {
+		msg := fmt.Sprintf("invalid PromQL query: %s", v.Err())
+		return Err(method, msg, v.Err(), logger)
+	}
+
+	// Optional
+	// Results are paged; cursor identifies the page
+	// Omitted and relative times are resolved from the time of the first page so that every page is of the same results
+	c, err := extractCursor(args["cursor"], nowFrom(ctx), logger)
+	if err != nil {
+		msg := "unable to extract 'cursor' parameter"
+		return Err(method, msg, err, logger)
+	}
+	now := c.now
+
+	// Optional
+	// Required by Prometheus API method
+	ts, err := extractTimestamp(args["time"], now, logger)
+	if err != nil {
+		msg := "unable to extract 'time' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// If time is omitted, use now so that it can be echoed
+	if ts.IsZero() {
+		ts = now
+	}
+	notes := []string{fmt.Sprintf("resolved: time=%s", formatTimestamp(ts))}
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	// Optional for Prometheus API method: timeout,limit
+	opts, err := extractOptions(args, logger)
+	if err != nil {
+		msg := "unable to extract optional arguments"
+		return Err(method, msg, err, logger)
+	}
+
+	value, queryNotes, e := x.query(ctx, query, ts, opts, logger)
+	if e != nil {
+		return Err(method, e.Msg, e.Err, logger)
+	}
+	notes = append(notes, queryNotes...)
+
+	text, pageNotes, err := x.pageValue(value, format, c)
+	if err != nil {
+		msg := "unable to render query results"
+		return Err(method, msg, err, logger)
+	}
+
+	return withNotes(mcp.NewToolResultText(text), append(notes, pageNotes...)), nil
+}
+
+// query is a method that applies the guardrails to an (instant) query and invokes Prometheus' Query method
+// It returns the query's results and any notes (e.g. clamping)
+func (x *Client) query(ctx context.Context, query string, ts time.Time, opts []v1.Option, logger *slog.Logger) (model.Value, []string, *errors.ErrToolHandler) {
+	// Estimate the query's cost and reject|clamp it if it exceeds the guardrails
+	guardOpts, guardNotes, err := x.guardQuery(ctx, query, ts, logger)
+	if err != nil {
+		msg := fmt.Sprintf("guardrails: %s", err)
+		return nil, nil, errors.NewErrToolHandler(msg, err)
+	}
+	opts = append(opts, guardOpts...)
+
+	// Invoke Prometheus Query method
+	value, warnings, err := x.v1api.Query(ctx, query, ts, opts...)
+	if err != nil {
+		msg := "unable to retrieve query results"
+		return nil, nil, errors.NewErrToolHandler(msg, err)
+	}
+
+	// If there are warnings, log them
+	if len(warnings) != 0 {
+		logger.Info("Warnings", "warnings", warnings)
+	}
+
+	return value, guardNotes, nil
+}
+
+// QueryRange is a method queries Promethues with PromQL and returns a range query
+func (x *Client) QueryRange(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "QueryRange"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	args := rqst.GetArguments()
+
+	// Required
+	query := args["query"].(string)
+
+	// Validate the query locally before sending it to Prometheus
+	if v := promql.Validate(query); !v.Valid {
+		msg := fmt.Sprintf("invalid PromQL query: %s", v.Err())
+		return Err(method, msg, v.Err(), logger)
+	}
+
+	// Optional
+	// Results are paged; cursor identifies the page
+	// Omitted and relative times are resolved from the time of the first page so that every page is of the same results
+	c, err := extractCursor(args["cursor"], nowFrom(ctx), logger)
+	if err != nil {
+		msg := "unable to extract 'cursor' parameter"
+		return Err(method, msg, err, logger)
+	}
+	now := c.now
+
+	end, err := extractTimestamp(args["end"], now, logger)
+	if err != nil {
+		msg := "unable to extract 'end' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	start, err := extractStart(args["start"], end, now, logger)
+	if err != nil {
+		msg := "unable to extract 'start' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	step, err := extractDuration(args["step"], logger)
+	if err != nil {
+		msg := "unable to extract 'step' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Create Range
+	r := v1.Range{
+		Start: start,
+		End:   end,
+		Step:  step,
+	}
+
+	// Echo the resolved times
+	notes := resolvedNotes(r.Start, r.End)
+
+	// If step is omitted, compute it from the range
+	// The computed step is echoed in the result
+	if r.Step == 0 {
+		var note string
+		r.Step, note = x.autoStep(ctx, query, r, logger)
+		notes = append(notes, note)
+	}
+
+	// Optional
+	summary, _ := args["summary"].(bool)
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	// Optional for Prometheus API method: timeout,limit
+	opts, err := extractOptions(args, logger)
+	if err != nil {
+		msg := "unable to extract optional arguments"
+		return Err(method, msg, err, logger)
+	}
+
+	// Estimate the query's cost and reject|clamp it if it exceeds the guardrails
+	r, guardOpts, guardNotes, err := x.guardQueryRange(ctx, query, r, logger)
+	if err != nil {
+		msg := fmt.Sprintf("guardrails: %s", err)
+		return Err(method, msg, err, logger)
+	}
+	opts = append(opts, guardOpts...)
+	notes = append(notes, guardNotes...)
+
+	// Invoke Prometheus QueryRange method
+	value, warnings, err := x.v1api.QueryRange(ctx, query, r, opts...)
+	if err != nil {
+		msg := "unable to query results"
+		return Err(method, msg, err, logger)
+	}
+
+	// If there are warnings, log them
+	if len(warnings) != 0 {
+		logger.Info("Warnings", "warnings", warnings)
+	}
+
+	// If requested (and the result is a matrix), summarize each series rather than return its samples
+	// Summaries are in the order of the (sorted) series so that pages are deterministic
+	if matrix, ok := value.(model.Matrix); ok && summary {
+		sort.Sort(matrix)
+		summaries := render.Summarize(matrix, r.Start, r.End, r.Step)
+
+		logger.Info("Query results summarized",
+			"series", len(summaries),
+		)
+
+		text, pageNotes, err := x.pageSummaries(summaries, format, c)
+		if err != nil {
+			msg := "unable to render query results summary"
+			return Err(method, msg, err, logger)
+		}
+
+		return withNotes(mcp.NewToolResultText(text), append(notes, pageNotes...)), nil
+	}
+
+	text, pageNotes, err := x.pageValue(value, format, c)
+	if err != nil {
+		msg := "unable to render query results"
+		return Err(method, msg, err, logger)
+	}
+
+	return withNotes(mcp.NewToolResultText(text), append(notes, pageNotes...)), nil
+}
+
+// Rules is a method that queries Prometheus for a list of Rules
+func (x *Client) Rules(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Rules"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Rules method
+	result, err := x.v1api.Rules(ctx)
+	if err != nil {
+		msg := "unable to retrieve rules"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Rules retrieved",
+		"rules", len(result.Groups),
+	)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		msg := "unable to marshal targets"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// Series is a method that queries Prometheus for a list of Series
+func (x *Client) Series(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Series"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	args := rqst.GetArguments()
+
+	// Required
 	matches, err := extractMatches(args["match[]"], logger)
 	if err != nil {
 		msg := "unable to extract repeated 'match[]' parameters"
 		return Err(method, msg, err, logger)
 	}
 
-	startTime, err := extractTimestamp(args["start"], logger)
+	// Optional
+	// Results are paged; cursor identifies the page
+	// Omitted and relative times are resolved from the time of the first page so that every page is of the same results
+	c, err := extractCursor(args["cursor"], nowFrom(ctx), logger)
 	if err != nil {
-		msg := "unable to extract 'start' parameter"
+		msg := "unable to extract 'cursor' parameter"
 		return Err(method, msg, err, logger)
 	}
+	now := c.now
 
-	endTime, err := extractTimestamp(args["end"], logger)
+	endTime, err := extractTimestamp(args["end"], now, logger)
 	if err != nil {
 		msg := "unable to extract 'end' parameter"
 		return Err(method, msg, err, logger)
 	}
 
+	startTime, err := extractStart(args["start"], endTime, now, logger)
+	if err != nil {
+		msg := "unable to extract 'start' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
 	// Optional
 	// Optional for Prometheus API method: timeout,limit
 	opts, err := extractOptions(args, logger)
@@ -548,6 +1325,9 @@ func (x *Client) Series(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Cal
 		return Err(method, msg, err, logger)
 	}
 
+	// Limit the number of series to the guardrails
+	opts = append(opts, x.guardSeries()...)
+
 	// Invoke Prometheus Series method
 	results, warnings, err := x.v1api.Series(ctx, matches, startTime, endTime, opts...)
 	if err != nil {
@@ -555,6 +1335,16 @@ func (x *Client) Series(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Cal
 		return Err(method, msg, err, logger)
 	}
 
+	// Reject|clamp the results if these exceed the guardrails
+	results, guardNotes, err := x.checkSeries(results, logger)
+	if err != nil {
+		msg := fmt.Sprintf("guardrails: %s", err)
+		return Err(method, msg, err, logger)
+	}
+
+	// Echo the resolved times
+	notes := append(resolvedNotes(startTime, endTime), guardNotes...)
+
 	logger.Info("Series retrieved",
 		"series", len(results),
 	)
@@ -564,11 +1354,165 @@ func (x *Client) Series(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Cal
 		logger.Info("Warnings", "warnings", warnings)
 	}
 
-	b, err := json.Marshal(results)
+	text, pageNotes, err := x.pageSeries(results, format, c)
 	if err != nil {
-		msg := "unable to marshal targets"
+		msg := "unable to render series"
+		return Err(method, msg, err, logger)
+	}
+
+	return withNotes(mcp.NewToolResultText(text), append(notes, pageNotes...)), nil
+}
+
+// StatusBuildinfo is a method that queries Prometheus for its build information
+func (x *Client) StatusBuildinfo(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "StatusBuildinfo"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Status Buildinfo method
+	buildinfo, err := x.v1api.Buildinfo(ctx)
+	if err != nil {
+		msg := "unable to retrieve build information"
+		return Err(method, msg, err, logger)
+	}
+
+	b, err := json.Marshal(buildinfo)
+	if err != nil {
+		msg := "unable to marshal build information"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// StatusConfig is a method that queries Prometheus for its (currently loaded) configuration
+func (x *Client) StatusConfig(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "StatusConfig"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// optional: sections
+	args := rqst.GetArguments()
+
+	// Optional
+	var sections []string
+	if v, ok := args["sections"]; ok {
+		var err error
+		sections, err = extractStrings(v, "sections", logger)
+		if err != nil {
+			msg := "unable to extract 'sections' parameter"
+			return Err(method, msg, err, logger)
+		}
+	}
+
+	// Invoke Prometheus Status Config method
+	config, err := x.v1api.Config(ctx)
+	if err != nil {
+		msg := "unable to retrieve configuration"
+		return Err(method, msg, err, logger)
+	}
+
+	// If no sections were requested, return the configuration (as YAML)
+	if len(sections) == 0 {
+		b, err := json.Marshal(config)
+		if err != nil {
+			msg := "unable to marshal configuration"
+			return Err(method, msg, err, logger)
+		}
+
+		return mcp.NewToolResultText(string(b)), nil
+	}
+
+	// Otherwise parse the YAML and return only the requested sections
+	parsed, err := parseConfigSections(config.YAML, sections, logger)
+	if err != nil {
+		msg := "unable to parse configuration sections"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Configuration sections retrieved",
+		"sections", sections,
+	)
+
+	b, err := json.Marshal(parsed)
+	if err != nil {
+		msg := "unable to marshal configuration sections"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// StatusFlags is a method that queries Prometheus for the flag values that it was launched with
+func (x *Client) StatusFlags(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "StatusFlags"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Status Flags method
+	flags, err := x.v1api.Flags(ctx)
+	if err != nil {
+		msg := "unable to retrieve flags"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Flags retrieved",
+		"flags", len(flags),
+	)
+
+	b, err := json.Marshal(flags)
+	if err != nil {
+		msg := "unable to marshal flags"
 		return Err(method, msg, err, logger)
 	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// StatusRuntimeinfo is a method that queries Prometheus for its runtime information
+func (x *Client) StatusRuntimeinfo(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "StatusRuntimeinfo"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Status Runtimeinfo method
+	runtimeinfo, err := x.v1api.Runtimeinfo(ctx)
+	if err != nil {
+		msg := "unable to retrieve runtime information"
+		return Err(method, msg, err, logger)
+	}
+
+	b, err := json.Marshal(runtimeinfo)
+	if err != nil {
+		msg := "unable to marshal runtime information"
+		return Err(method, msg, err, logger)
+	}
+
 	return mcp.NewToolResultText(string(b)), nil
 }
 
@@ -600,6 +1544,34 @@ func (x *Client) StatusTSDB(ctx context.Context, rqst mcp.CallToolRequest) (*mcp
 	return mcp.NewToolResultText(string(b)), nil
 }
 
+// StatusWalReplay is a method that queries Prometheus for the status of its write-ahead log (WAL) replay
+func (x *Client) StatusWalReplay(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "StatusWalReplay"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Status WAL Replay method
+	walreplay, err := x.v1api.WalReplay(ctx)
+	if err != nil {
+		msg := "unable to retrieve WAL replay status"
+		return Err(method, msg, err, logger)
+	}
+
+	b, err := json.Marshal(walreplay)
+	if err != nil {
+		msg := "unable to marshal WAL replay status"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
 // Targets is a method that queries Prometheus for a list of Targets
 func (x *Client) Targets(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	method := "Targets"
@@ -612,6 +1584,17 @@ func (x *Client) Targets(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Ca
 		"tool": method,
 	}).Inc()
 
+	// Tool provides arguments; retrieve these
+	// optional: output
+	args := rqst.GetArguments()
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
 	// Invoke Prometheus Targets method
 	result, err := x.v1api.Targets(ctx)
 	if err != nil {
@@ -624,9 +1607,100 @@ func (x *Client) Targets(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.Ca
 		"dropped", len(result.Dropped),
 	)
 
-	b, err := json.Marshal(result)
+	text, err := output(format, result, func() *render.Result {
+		return render.Targets(result)
+	})
 	if err != nil {
-		msg := "unable to marshal targets"
+		msg := "unable to render targets"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(text), nil
+}
+
+// TargetsMetadata is a method that queries Prometheus for the Metadata (type, help, unit) of Metrics scraped by Targets
+func (x *Client) TargetsMetadata(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "TargetsMetadata"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// optional: match_target, metric, limit
+	args := rqst.GetArguments()
+
+	// Optional
+	// Prometheus API method uses "" to represent all targets|metrics
+	matchTarget, _ := args["match_target"].(string)
+	metric, _ := args["metric"].(string)
+
+	// Optional
+	// Prometheus API method uses "" to represent no limit
+	limit, err := extractLimit(args["limit"], logger)
+	if err != nil {
+		msg := "unable to extract 'limit' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus TargetsMetadata method
+	metadata, err := x.v1api.TargetsMetadata(ctx, matchTarget, metric, formatLimit(limit))
+	if err != nil {
+		msg := "unable to retrieve targets metadata"
+		return Err(method, msg, err, logger)
+	}
+
+	logger.Info("Targets metadata retrieved",
+		"metadata", len(metadata),
+	)
+
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		msg := "unable to marshal targets metadata"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
+
+// ValidateQuery is a method that validates a PromQL expression locally (without querying Prometheus)
+func (x *Client) ValidateQuery(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "ValidateQuery"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// required: query
+	args := rqst.GetArguments()
+
+	// Required
+	query, ok := args["query"].(string)
+	if !ok {
+		msg := "unable to extract 'query' parameter"
+		return Err(method, msg, nil, logger)
+	}
+
+	// Parse the query locally
+	// An invalid query is a successful validation
+	v := promql.Validate(query)
+
+	logger.Info("Query validated",
+		"valid", v.Valid,
+	)
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		msg := "unable to marshal validation"
 		return Err(method, msg, err, logger)
 	}
 
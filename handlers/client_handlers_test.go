@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/DazWilkin/prometheus-mcp-server/testdata"
@@ -96,6 +97,402 @@ func TestExemplars(t *testing.T) {
 	t.Skip("Test not implemented but covered by tools tests")
 }
 
+// TestFormatQuery tests FormatQuery
+// https://prometheus.io/docs/prometheus/latest/querying/api/#formatting-query-expressions
+func TestFormatQuery(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Experimental functions (e.g. sort_by_label) aren't enabled in the local parser
+	// Prometheus (if run with --enable-feature=promql-experimental-functions) can format these
+	experimental := `sort_by_label(up,"job")`
+	formatted := `sort_by_label(up, "job")`
+
+	calls := 0
+	mux.HandleFunc("/api/v1/format_query", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.URL.Query().Get("query"); got != experimental {
+			msg := "unexpected query"
+			t.Logf("%s: %s", msg, got)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		resp := fmt.Sprintf(`{"data":%q,"status":"success"}`, formatted)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		calls int
+	}{
+		{
+			name:  "local",
+			query: `sum(rate(up{job="prometheus"}[5m]))by(job)`,
+			want:  `sum by (job) (rate(up{job="prometheus"}[5m]))`,
+			calls: 0,
+		},
+		{
+			name:  "prometheus",
+			query: experimental,
+			want:  formatted,
+			calls: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = 0
+
+			rqst := mcp.CallToolRequest{
+				Request: mcp.Request{
+					Method: "tools/call",
+				},
+				Params: mcp.CallToolParams{
+					Name: "FormatQuery",
+					Arguments: map[string]any{
+						"query": test.query,
+					},
+				},
+			}
+			resp, err := c.FormatQuery(context.Background(), rqst)
+			if err != nil {
+				t.Fatalf("unable to invoke FormatQuery method: %+v", err)
+			}
+
+			got := resp.Content[0].(mcp.TextContent).Text
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+
+			if calls != test.calls {
+				t.Errorf("got: %d calls to Prometheus; want: %d", calls, test.calls)
+			}
+		})
+	}
+}
+
+// TestLabelValues tests LabelValues
+// https://prometheus.io/docs/prometheus/latest/querying/api/#querying-label-values
+func TestLabelValues(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := string(testdata.JsonLabelValues)
+
+	mux.HandleFunc("/api/v1/label/job/values", func(w http.ResponseWriter, r *http.Request) {
+		// Expect the repeated "match[]" parameter to be passed through
+		if got := r.URL.Query()["match[]"]; len(got) != 1 {
+			msg := "expected a single 'match[]' parameter"
+			t.Logf("%s: %+q", msg, got)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		data := want
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	mockPrometheus := server.URL
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: mockPrometheus,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "LabelValues",
+			Arguments: map[string]any{
+				"label": "job",
+				"match[]": []any{
+					"up",
+				},
+			},
+		},
+	}
+	resp, err := c.LabelValues(context.Background(), rqst)
+	if err != nil {
+		t.Fatalf("unable to invoke LabelValues method: %+v", err)
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	if len(resp.Content) == 0 {
+		t.Errorf("expected content")
+	}
+
+	content := resp.Content[0].(mcp.TextContent)
+	if content.Type != "text" {
+		t.Errorf("expected text content")
+	}
+
+	got := content.Text
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestLabels tests Labels
+// https://prometheus.io/docs/prometheus/latest/querying/api/#getting-label-names
+func TestLabels(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := string(testdata.JsonLabelNames)
+
+	mux.HandleFunc("/api/v1/labels", func(w http.ResponseWriter, r *http.Request) {
+
+		data := want
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	mockPrometheus := server.URL
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: mockPrometheus,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name:      "Labels",
+			Arguments: map[string]any{},
+		},
+	}
+	resp, err := c.Labels(context.Background(), rqst)
+	if err != nil {
+		t.Fatalf("unable to invoke Labels method: %+v", err)
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	if len(resp.Content) == 0 {
+		t.Errorf("expected content")
+	}
+
+	content := resp.Content[0].(mcp.TextContent)
+	if content.Type != "text" {
+		t.Errorf("expected text content")
+	}
+
+	got := content.Text
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestLintQuery tests LintQuery
+// Metadata and target scrape intervals are retrieved from Prometheus
+func TestLintQuery(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/metadata", func(w http.ResponseWriter, r *http.Request) {
+		data := "{}"
+		if r.URL.Query().Get("metric") == "up" {
+			data = string(testdata.JsonMetadata)
+		}
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/api/v1/targets", func(w http.ResponseWriter, r *http.Request) {
+		data := `{"activeTargets":[{"discoveredLabels":{"__scrape_interval__":"15s"},"labels":{"job":"prometheus"},"scrapePool":"prometheus","scrapeUrl":"http://localhost:9090/metrics","health":"up"}],"droppedTargets":[]}`
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	tests := map[string]string{
+		// up is a gauge (metadata)
+		`rate(up{job="prometheus"}[5m])`: "counter-function-on-gauge",
+		// prometheus is scraped every 15s (targets)
+		`rate(prometheus_http_requests_total{job="prometheus"}[15s])`: "range-shorter-than-scrape-interval",
+	}
+	for query, want := range tests {
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name: "LintQuery",
+				Arguments: map[string]any{
+					"query": query,
+				},
+			},
+		}
+		resp, err := c.LintQuery(context.Background(), rqst)
+		if err != nil {
+			t.Fatalf("unable to invoke LintQuery method: %+v", err)
+		}
+
+		t.Logf("Response: %+v", resp)
+
+		if resp.IsError {
+			t.Fatalf("expected success")
+		}
+
+		content := resp.Content[0].(mcp.TextContent)
+		if !strings.Contains(content.Text, fmt.Sprintf(`"rule":%q`, want)) {
+			t.Errorf("got:\n%s\nwant: %s", content.Text, want)
+		}
+	}
+}
+
+// TestMetadata tests Metadata
+// https://prometheus.io/docs/prometheus/latest/querying/api/#querying-metric-metadata
+func TestMetadata(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	want := string(testdata.JsonMetadata)
+
+	mux.HandleFunc("/api/v1/metadata", func(w http.ResponseWriter, r *http.Request) {
+		// Expect the optional "metric" and "limit" parameters to be passed through
+		values := r.URL.Query()
+		if values.Get("metric") != "up" || values.Get("limit") != "1" {
+			msg := "unexpected query string"
+			t.Logf("%s: %+v", msg, values)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		data := want
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	mockPrometheus := server.URL
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: mockPrometheus,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "Metadata",
+			Arguments: map[string]any{
+				"metric": "up",
+				// JSON numbers are decoded as float64
+				"limit": float64(1),
+			},
+		},
+	}
+	resp, err := c.Metadata(context.Background(), rqst)
+	if err != nil {
+		t.Fatalf("unable to invoke Metadata method: %+v", err)
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	if len(resp.Content) == 0 {
+		t.Errorf("expected content")
+	}
+
+	content := resp.Content[0].(mcp.TextContent)
+	if content.Type != "text" {
+		t.Errorf("expected text content")
+	}
+
+	got := content.Text
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 // TestMetrics test Metrics
 // https://prometheus.io/docs/prometheus/latest/querying/api/#querying-label-values
 func TestMetrics(t *testing.T) {
@@ -281,6 +678,53 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+// TestQueryInvalid tests that Query validates queries locally before sending them to Prometheus
+func TestQueryInvalid(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected query to be rejected before it's sent to Prometheus")
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "Query",
+			Arguments: map[string]any{
+				"query": "sum(rate(up[5m])",
+			},
+		},
+	}
+	resp, err := c.Query(context.Background(), rqst)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	content := resp.Content[0].(mcp.TextContent)
+	want := "invalid PromQL query: 1:17:"
+	if !strings.HasPrefix(content.Text, want) {
+		t.Errorf("got:\n%s\nwant (prefix):\n%s", content.Text, want)
+	}
+}
+
 func TestQueryRange(t *testing.T) {
 	t.Skip("Test not implemented but covered by tools tests")
 }
@@ -293,10 +737,192 @@ func TestSeries(t *testing.T) {
 	t.Skip("Test not implemented but covered by tools tests")
 }
 
+// TestSeriesOutput tests Series' tabular output
+func TestSeriesOutput(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		data := testdata.JsonSeries
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "Series",
+			Arguments: map[string]any{
+				"match[]": []any{"up"},
+				"output":  "csv",
+			},
+		},
+	}
+	resp, err := c.Series(context.Background(), rqst)
+	if err != nil {
+		t.Fatalf("unable to invoke Series method: %+v", err)
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	// Labels common to all series are factored out
+	want := `# common: {__name__="up"}
+instance,job
+localhost:9090,prometheus
+localhost:9100,node
+`
+	got := resp.Content[0].(mcp.TextContent).Text
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Unsupported formats are rejected
+	rqst.Params.Arguments = map[string]any{
+		"match[]": []any{"up"},
+		"output":  "yaml",
+	}
+	if _, err := c.Series(context.Background(), rqst); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestStatusBuildinfo(t *testing.T) {
+	t.Skip("Test not implemented but covered by tools tests")
+}
+
+// TestStatusConfig tests StatusConfig
+// https://prometheus.io/docs/prometheus/latest/querying/api/#config
+func TestStatusConfig(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/status/config", func(w http.ResponseWriter, r *http.Request) {
+
+		data := testdata.JsonConfigResult
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	mockPrometheus := server.URL
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: mockPrometheus,
+	})
+	if err != nil {
+		t.Errorf("unable to create Prometheus API client")
+	}
+
+	c := NewClient(apiClient, logger)
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{
+			name: "yaml",
+			args: map[string]any{},
+			want: string(testdata.JsonConfigResult),
+		},
+		{
+			name: "sections",
+			args: map[string]any{
+				"sections": []any{
+					"rule_files",
+					"remote_write",
+				},
+			},
+			want: `{"remote_write":null,"rule_files":["/etc/prometheus/rules.yml"]}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := mcp.CallToolRequest{
+				Request: mcp.Request{
+					Method: "tools/call",
+				},
+				Params: mcp.CallToolParams{
+					Name:      "StatusConfig",
+					Arguments: test.args,
+				},
+			}
+			resp, err := c.StatusConfig(context.Background(), rqst)
+			if err != nil {
+				t.Fatalf("unable to invoke StatusConfig method: %+v", err)
+			}
+
+			t.Logf("Response: %+v", resp)
+
+			if len(resp.Content) == 0 {
+				t.Fatalf("expected content")
+			}
+
+			content := resp.Content[0].(mcp.TextContent)
+			if content.Type != "text" {
+				t.Errorf("expected text content")
+			}
+
+			got := content.Text
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStatusFlags(t *testing.T) {
+	t.Skip("Test not implemented but covered by tools tests")
+}
+
+func TestStatusRuntimeinfo(t *testing.T) {
+	t.Skip("Test not implemented but covered by tools tests")
+}
+
 func TestStatusTSDB(t *testing.T) {
 	t.Skip("Test not implemented but covered by tools tests")
 }
 
+func TestStatusWalReplay(t *testing.T) {
+	t.Skip("Test not implemented but covered by tools tests")
+}
+
 func TestTargets(t *testing.T) {
 	t.Skip("Test not implemented but covered by tools tests")
 }
+
+func TestTargetsMetadata(t *testing.T) {
+	t.Skip("Test not implemented but covered by tools tests")
+}
+
+func TestValidateQuery(t *testing.T) {
+	t.Skip("Test not implemented but covered by promql tests")
+}
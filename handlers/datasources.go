@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/policy"
+	"github.com/DazWilkin/prometheus-mcp-server/render"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Datasource is a type that represents a named Prometheus server and its tools
+type Datasource struct {
+	Name   string
+	URL    string
+	Client *Client
+	Meta   *Meta
+	// Tenant constrains the tenants that tool calls may specify using the 'tenant' argument
+	Tenant config.Tenant
+}
+
+// tools is a method that returns the datasource's Client and Meta tools
+func (d Datasource) tools() []server.ServerTool {
+	tools := []server.ServerTool{}
+	if d.Client != nil {
+		tools = append(tools, d.Client.Tools()...)
+	}
+	if d.Meta != nil {
+		tools = append(tools, d.Meta.Tools()...)
+	}
+	return tools
+}
+
+// Datasources is a type that represents a set of named Prometheus servers
+// Tools are published once and each call is dispatched to the datasource named by its 'datasource' argument
+type Datasources struct {
+	// datasources are ordered; the first is the default
+	datasources []Datasource
+	// queryAll configures queries of every datasource
+	queryAll config.QueryAll
+	// policy (if any) determines the tools and series that principals may access
+	policy *policy.Policy
+	// tools configures the tools that are published
+	tools  config.Tools
+	logger *slog.Logger
+}
+
+// DatasourcesOption is a type that represents an optional configuration of Datasources
+type DatasourcesOption func(*Datasources)
+
+// NewDatasources is a function that creates a new Datasources
+// The first datasource is the default
+func NewDatasources(datasources []Datasource, logger *slog.Logger, opts ...DatasourcesOption) *Datasources {
+	x := &Datasources{
+		datasources: datasources,
+		queryAll: config.QueryAll{
+			Concurrency: defaultConcurrency,
+		},
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
+}
+
+// Tools is a method that returns the MCP server tools of every datasource
+// Tools are defined by the default datasource and gain an optional "datasource" argument
+// If any datasource allows tenants, tools also gain an optional "tenant" argument
+// Only the configured tools (see WithTools) are published
+func (x *Datasources) Tools() []server.ServerTool {
+	method := "tools"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	if len(x.datasources) == 0 {
+		logger.Error("no datasources configured")
+		return nil
+	}
+
+	// handlers maps datasource names to tool names to handlers
+	names := make([]string, len(x.datasources))
+	tenants := []string{}
+	handlers := make(map[string]map[string]server.ToolHandlerFunc, len(x.datasources))
+	for i, d := range x.datasources {
+		names[i] = d.Name
+		for _, tenant := range d.Tenant.Allowed {
+			if !slices.Contains(tenants, tenant) {
+				tenants = append(tenants, tenant)
+			}
+		}
+		handlers[d.Name] = map[string]server.ToolHandlerFunc{}
+		for _, tool := range d.tools() {
+			handlers[d.Name][tool.Tool.Name] = x.tenant(tool.Handler, d)
+		}
+	}
+
+	tools := []server.ServerTool{
+		{
+			Tool: mcp.NewTool(
+				"datasources",
+				mcp.WithDescription("List the Prometheus datasources (the 'datasource' argument of other tools) and their health"),
+			),
+			Handler: x.Datasources,
+		},
+		// Results are paged using the default datasource's response budget
+		x.datasources[0].Client.budget(server.ServerTool{
+			Tool: mcp.NewTool(
+				"query_all",
+				mcp.WithDescription("Query every Prometheus datasource with PromQL and return the merged instant query results; series are labeled by datasource"),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Prometheus expression query string"),
+				),
+				mcp.WithString("time",
+					mcp.Description("Evaluation timestamp (RFC-3339, Unix epoch or relative e.g. now-5m, now/h). Defaults to now"),
+				),
+				mcp.WithString("timeout",
+					mcp.Description("Evaluation timeout"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of returned series per datasource"),
+				),
+				mcp.WithString("output",
+					mcp.Enum(render.Formats...),
+					mcp.Description("Output format: json (default) or, to reduce tokens, table, csv or markdown with labels common to all results factored out"),
+				),
+			),
+			Handler: x.QueryAll,
+		}),
+	}
+	for _, tool := range x.datasources[0].tools() {
+		tools = append(tools, x.dispatch(tool, names, tenants, handlers))
+	}
+
+	tools = x.filter(tools)
+	for i, tool := range tools {
+		tools[i] = x.enforce(tool)
+	}
+
+	return tools
+}
+
+// dispatch is a method that dispatches a tool's calls to the datasource named by the "datasource" argument
+// If omitted, calls are dispatched to the default datasource
+func (x *Datasources) dispatch(tool server.ServerTool, names, tenants []string, handlers map[string]map[string]server.ToolHandlerFunc) server.ServerTool {
+	if tool.Tool.InputSchema.Properties == nil {
+		tool.Tool.InputSchema.Properties = map[string]any{}
+	}
+	tool.Tool.InputSchema.Properties["datasource"] = map[string]any{
+		"type":        "string",
+		"description": fmt.Sprintf("Name of the Prometheus datasource (default: %s); see the datasources tool", names[0]),
+		"enum":        names,
+	}
+	if len(tenants) != 0 {
+		tool.Tool.InputSchema.Properties["tenant"] = map[string]any{
+			"type":        "string",
+			"description": "Tenant (e.g. Mimir's X-Scope-OrgID) of the datasource's requests (default: the datasource's default tenant)",
+			"enum":        tenants,
+		}
+	}
+
+	name := tool.Tool.Name
+	tool.Handler = func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		method := rqst.Params.Name
+		logger := x.logger.With("method", method)
+
+		datasource, _ := rqst.GetArguments()["datasource"].(string)
+		if datasource == "" {
+			datasource = names[0]
+		}
+
+		tools, ok := handlers[datasource]
+		if !ok {
+			msg := fmt.Sprintf("unknown datasource %q (expected one of: %s)", datasource, strings.Join(names, ", "))
+			return Err(method, msg, nil, logger)
+		}
+		handler, ok := tools[name]
+		if !ok {
+			msg := fmt.Sprintf("tool %q isn't available for datasource %q", name, datasource)
+			return Err(method, msg, nil, logger)
+		}
+
+		logger.Debug("Dispatching", "datasource", datasource)
+		return handler(ctx, rqst)
+	}
+
+	return tool
+}
+
+// readyTimeout is the maximum duration of a datasource's readiness check
+var readyTimeout = 5 * time.Second
+
+// status is a type that represents a datasource's health
+type status struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Default bool   `json:"default"`
+	// Healthy is whether the datasource is ready (using its backend-specific readiness path)
+	Healthy bool   `json:"healthy"`
+	Status  string `json:"status"`
+}
+
+// Datasources is a method that lists the datasources and checks their readiness using the Management API
+// Each datasource is checked concurrently and bounded by readyTimeout so that an unresponsive datasource doesn't block the others
+func (x *Datasources) Datasources(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Datasources"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Datasources are checked concurrently
+	statuses := make([]status, len(x.datasources))
+	var wg sync.WaitGroup
+	for i, d := range x.datasources {
+		statuses[i] = status{
+			Name:    d.Name,
+			URL:     d.URL,
+			Default: i == 0,
+		}
+		if d.Meta == nil {
+			statuses[i].Status = "unknown"
+			continue
+		}
+
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+			defer cancel()
+
+			respCode, body, err := d.Meta.client.Ready(ctx)
+			switch {
+			case err != nil:
+				statuses[i].Status = err.Error()
+			case respCode != http.StatusOK:
+				statuses[i].Status = fmt.Sprintf("%d (%s): %s", respCode, http.StatusText(respCode), body)
+			default:
+				statuses[i].Healthy = true
+				statuses[i].Status = body
+			}
+		})
+	}
+	wg.Wait()
+
+	b, err := json.Marshal(statuses)
+	if err != nil {
+		msg := "unable to marshal datasources"
+		return Err(method, msg, err, logger)
+	}
+
+	return mcp.NewToolResultText(string(b)), nil
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/transport"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// newDatasource is a function that creates a Datasource for a mock Prometheus server
+// The server responds to queries with its name (as the value of the "datasource" label) and to readiness checks with code
+func newDatasource(t *testing.T, name string, code int, logger *slog.Logger) (Datasource, *httptest.Server) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		resp := `{"data":{"resultType":"vector","result":[{"metric":{"datasource":"` + name + `"},"value":[1749772800,"1"]}]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/-/ready", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, http.StatusText(code), code)
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	return Datasource{
+		Name:   name,
+		URL:    server.URL,
+		Client: NewClient(apiClient, logger),
+		Meta:   NewMeta(server.URL, logger),
+	}, server
+}
+
+// TestDatasources tests that tool calls are dispatched to the datasource named by the 'datasource' argument
+func TestDatasources(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	east, eastServer := newDatasource(t, "east", http.StatusOK, logger)
+	defer eastServer.Close()
+	west, westServer := newDatasource(t, "west", http.StatusServiceUnavailable, logger)
+	defer westServer.Close()
+
+	tools := map[string]server.ServerTool{}
+	for _, tool := range NewDatasources([]Datasource{east, west}, logger).Tools() {
+		tools[tool.Tool.Name] = tool
+	}
+
+	// Every (Client|Meta) tool has the 'datasource' argument
+	for name, tool := range tools {
+		if _, ok := tool.Tool.InputSchema.Properties["datasource"]; !ok && name != "datasources" && name != "query_all" {
+			t.Errorf("%s: expected 'datasource' property", name)
+		}
+	}
+
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name:      name,
+				Arguments: args,
+			},
+		}
+		resp, _ := tools[name].Handler(context.Background(), rqst)
+		t.Logf("Response: %+v", resp)
+		return resp
+	}
+
+	tests := []struct {
+		datasource string
+		want       string
+	}{
+		{datasource: "", want: "east"},
+		{datasource: "east", want: "east"},
+		{datasource: "west", want: "west"},
+	}
+	for _, test := range tests {
+		resp := call("query", map[string]any{"query": "up", "datasource": test.datasource})
+		if resp.IsError {
+			t.Fatalf("%q: unexpected error", test.datasource)
+		}
+		if text := resp.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"datasource":"`+test.want+`"`) {
+			t.Errorf("%q: got: %s; want: %s", test.datasource, text, test.want)
+		}
+	}
+
+	if resp := call("query", map[string]any{"query": "up", "datasource": "north"}); !resp.IsError {
+		t.Errorf("expected unknown datasource error")
+	}
+
+	// Health
+	resp := call("datasources", map[string]any{})
+	got := []status{}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("unable to unmarshal datasources: %+v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got: %d datasources; want: 2", len(got))
+	}
+	if got[0].Name != "east" || !got[0].Default || !got[0].Healthy {
+		t.Errorf("got: %+v; want: healthy default east", got[0])
+	}
+	if got[1].Name != "west" || got[1].Default || got[1].Healthy {
+		t.Errorf("got: %+v; want: unhealthy west", got[1])
+	}
+}
+
+// TestDatasourcesReady tests that datasources are checked using their backend-specific readiness path and that checks are bounded
+func TestDatasourcesReady(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	defer func(d time.Duration) { readyTimeout = d }(readyTimeout)
+	readyTimeout = 100 * time.Millisecond
+
+	// Mimir (and Cortex) serve the readiness check at /ready and don't serve /-/healthy
+	mimirMux := http.NewServeMux()
+	mimirMux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ready"))
+	})
+	mimirServer := httptest.NewServer(mimirMux)
+	defer mimirServer.Close()
+
+	// The hung server doesn't respond until the check is canceled
+	hungServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hungServer.Close()
+
+	d := NewDatasources([]Datasource{
+		{
+			Name: "mimir",
+			URL:  mimirServer.URL + "/prometheus",
+			Meta: NewMeta(mimirServer.URL+"/prometheus", logger, WithReadyPath("/ready")),
+		},
+		{
+			Name: "hung",
+			URL:  hungServer.URL,
+			Meta: NewMeta(hungServer.URL, logger),
+		},
+	}, logger)
+
+	start := time.Now()
+	resp, err := d.Datasources(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unable to invoke Datasources method: %+v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*readyTimeout {
+		t.Errorf("got: %s; want: less than %s", elapsed, 5*readyTimeout)
+	}
+
+	got := []status{}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &got); err != nil {
+		t.Fatalf("unable to unmarshal datasources: %+v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got: %d datasources; want: 2", len(got))
+	}
+	if !got[0].Healthy {
+		t.Errorf("got: %+v; want: healthy mimir", got[0])
+	}
+	if got[1].Healthy {
+		t.Errorf("got: %+v; want: unhealthy hung", got[1])
+	}
+}
+
+// TestDatasourcesTenant tests that tool calls identify the default tenant or the allowed tenant of the 'tenant' argument
+func TestDatasourcesTenant(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// The server responds to queries with the tenant (as the value of the "tenant" label)
+	mux := http.NewServeMux()
+	mimirServer := httptest.NewServer(mux)
+	defer mimirServer.Close()
+
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		resp := `{"data":{"resultType":"vector","result":[{"metric":{"tenant":"` + r.Header.Get(config.DefaultTenantHeader) + `"},"value":[1749772800,"1"]}]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	tenant := config.Tenant{
+		Default: "team-a",
+		Allowed: config.Tenants{"team-a", "team-b"},
+	}
+	rt, err := transport.New(config.HTTPClient{Tenant: tenant})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+	apiClient, err := api.NewClient(api.Config{
+		Address:      mimirServer.URL,
+		RoundTripper: rt,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	datasource := Datasource{
+		Name:   "mimir",
+		URL:    mimirServer.URL,
+		Client: NewClient(apiClient, logger),
+		Tenant: tenant,
+	}
+
+	tools := map[string]server.ServerTool{}
+	for _, tool := range NewDatasources([]Datasource{datasource}, logger).Tools() {
+		tools[tool.Tool.Name] = tool
+	}
+	if _, ok := tools["query"].Tool.InputSchema.Properties["tenant"]; !ok {
+		t.Errorf("expected 'tenant' property")
+	}
+
+	tests := []struct {
+		tenant string
+		want   string
+		ok     bool
+	}{
+		{tenant: "", want: "team-a", ok: true},
+		{tenant: "team-b", want: "team-b", ok: true},
+		{tenant: "team-c", ok: false},
+	}
+	for _, test := range tests {
+		rqst := mcp.CallToolRequest{
+			Request: mcp.Request{
+				Method: "tools/call",
+			},
+			Params: mcp.CallToolParams{
+				Name:      "query",
+				Arguments: map[string]any{"query": "up", "tenant": test.tenant},
+			},
+		}
+		resp, _ := tools["query"].Handler(context.Background(), rqst)
+		t.Logf("Response: %+v", resp)
+
+		if resp.IsError == test.ok {
+			t.Fatalf("%q: got error: %t; want ok: %t", test.tenant, resp.IsError, test.ok)
+		}
+		if !test.ok {
+			continue
+		}
+		if text := resp.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"tenant":"`+test.want+`"`) {
+			t.Errorf("%q: got: %s; want: %s", test.tenant, text, test.want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	// lookback is Prometheus' (default) lookback delta; instant vectors select series with samples in this window
+	lookback time.Duration = 5 * time.Minute
+	// narrow is advice on reducing the number of series selected by a query
+	narrow string = "narrow the query with more specific label matchers (e.g. {job=\"...\"}) or aggregation (e.g. sum by (job) (...))"
+)
+
+// WithGuardrails is a function that configures the budgets that protect Prometheus from expensive queries
+func WithGuardrails(guardrails config.Guardrails) ClientOption {
+	return func(x *Client) {
+		x.guardrails = guardrails
+	}
+}
+
+// estimateSeries is a method that estimates the number of series selected by a PromQL expression at a time
+// It requests the series of each of the expression's (distinct) selectors from Prometheus (over the lookback window)
+// At most limit series are requested so estimating the cost of a query is never itself expensive
+// If the estimate is limit, the expression selects at least limit series
+// This overestimates the number of series returned by aggregations but it's the number of series that Prometheus must load
+func (x *Client) estimateSeries(ctx context.Context, query string, ts time.Time, limit uint64, logger *slog.Logger) (uint64, error) {
+	expr, err := promql.Parse(query)
+	if err != nil {
+		return 0, err
+	}
+
+	type selector struct {
+		matchers string
+		offset   time.Duration
+	}
+	selectors := []selector{}
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			matchers := make([]string, len(vs.LabelMatchers))
+			for i, m := range vs.LabelMatchers {
+				matchers[i] = m.String()
+			}
+			s := selector{
+				matchers: fmt.Sprintf("{%s}", strings.Join(matchers, ",")),
+				offset:   vs.OriginalOffset,
+			}
+			if !slices.Contains(selectors, s) {
+				selectors = append(selectors, s)
+			}
+		}
+		return nil
+	})
+
+	var series uint64
+	for _, s := range selectors {
+		end := ts.Add(-s.offset)
+		results, _, err := x.v1api.Series(ctx, []string{s.matchers}, end.Add(-lookback), end, v1.WithLimit(limit-series))
+		if err != nil {
+			return 0, err
+		}
+
+		series += min(uint64(len(results)), limit-series)
+		if series >= limit {
+			break
+		}
+	}
+
+	logger.Debug("Estimated series",
+		"selectors", len(selectors),
+		"series", series,
+		"limit", limit,
+	)
+
+	return series, nil
+}
+
+// guardQuery is a method that applies the guardrails to an instant query
+// It returns options (e.g. a limit) to apply to the query and notes describing any clamping
+func (x *Client) guardQuery(ctx context.Context, query string, ts time.Time, logger *slog.Logger) ([]v1.Option, []string, error) {
+	g := x.guardrails
+	if g.MaxSeries == 0 {
+		return nil, nil, nil
+	}
+
+	// One more than the budget is requested so that exceeding the budget can be detected
+	series, err := x.estimateSeries(ctx, query, ts, g.MaxSeries+1, logger)
+	if err != nil {
+		msg := "unable to estimate query cost"
+		return nil, nil, errors.NewErrToolHandler(msg, err)
+	}
+
+	if series <= g.MaxSeries {
+		return nil, nil, nil
+	}
+
+	if g.Clamp {
+		note := fmt.Sprintf("guardrails: results limited to %d series; the query selects more than %d series", g.MaxSeries, g.MaxSeries)
+		logger.Warn("Query clamped", "max_series", g.MaxSeries)
+		return []v1.Option{v1.WithLimit(g.MaxSeries)}, []string{note}, nil
+	}
+
+	msg := fmt.Sprintf("query selects more than %d series which exceeds the budget (--guardrails.max-series); %s",
+		g.MaxSeries,
+		narrow,
+	)
+	return nil, nil, errors.NewErrToolHandler(msg, nil)
+}
+
+// guardQueryRange is a method that applies the guardrails to a range query
+// It returns the (possibly clamped) range, options (e.g. a limit) to apply to the query and notes describing any clamping
+func (x *Client) guardQueryRange(ctx context.Context, query string, r v1.Range, logger *slog.Logger) (v1.Range, []v1.Option, []string, error) {
+	g := x.guardrails
+	opts := []v1.Option{}
+	notes := []string{}
+
+	// Range
+	if d := r.End.Sub(r.Start); g.MaxRange != 0 && d > g.MaxRange {
+		if !g.Clamp {
+			msg := fmt.Sprintf("query_range spans %s which exceeds the budget of %s (--guardrails.max-range); shorten the range by moving 'start' closer to 'end'",
+				model.Duration(d),
+				model.Duration(g.MaxRange),
+			)
+			return r, nil, nil, errors.NewErrToolHandler(msg, nil)
+		}
+
+		r.Start = r.End.Add(-g.MaxRange)
+		notes = append(notes, fmt.Sprintf("guardrails: start moved to %s to limit the range to %s",
+			r.Start.Format(time.RFC3339),
+			model.Duration(g.MaxRange),
+		))
+	}
+
+	if g.MaxSeries == 0 && g.MaxPoints == 0 {
+		return r, opts, notes, nil
+	}
+
+	// One more than the budget is requested so that exceeding the budget can be detected
+	// Each series returns at least one point so, without a series budget, more than max points series exceed the points budget
+	limit := g.MaxPoints + 1
+	if g.MaxSeries != 0 {
+		limit = g.MaxSeries + 1
+	}
+
+	// Series are estimated at the end of the range
+	series, err := x.estimateSeries(ctx, query, r.End, limit, logger)
+	if err != nil {
+		msg := "unable to estimate query cost"
+		return r, nil, nil, errors.NewErrToolHandler(msg, err)
+	}
+
+	if g.MaxSeries != 0 && series > g.MaxSeries {
+		if !g.Clamp {
+			msg := fmt.Sprintf("query_range selects more than %d series which exceeds the budget (--guardrails.max-series); %s",
+				g.MaxSeries,
+				narrow,
+			)
+			return r, nil, nil, errors.NewErrToolHandler(msg, nil)
+		}
+
+		opts = append(opts, v1.WithLimit(g.MaxSeries))
+		notes = append(notes, fmt.Sprintf("guardrails: results limited to %d series; the query selects more than %d series", g.MaxSeries, g.MaxSeries))
+		series = g.MaxSeries
+	}
+
+	if g.MaxPoints == 0 || series == 0 || r.Step <= 0 {
+		return r, opts, notes, nil
+	}
+
+	d := r.End.Sub(r.Start)
+	steps := uint64(d/r.Step) + 1
+	if points := series * steps; points > g.MaxPoints {
+		// The smallest step that keeps the points within budget
+		// Rounded up to a whole second
+		step, ok := minStep(d, series, g.MaxPoints)
+		if !g.Clamp || !ok {
+			hint := narrow
+			if ok {
+				hint = fmt.Sprintf("increase 'step' to at least %s, shorten the range or %s", model.Duration(step), narrow)
+			}
+			msg := fmt.Sprintf("query_range returns an estimated %d points (%d series x %d steps) which exceeds the budget of %d points (--guardrails.max-points); %s",
+				points,
+				series,
+				steps,
+				g.MaxPoints,
+				hint,
+			)
+			return r, nil, nil, errors.NewErrToolHandler(msg, nil)
+		}
+
+		notes = append(notes, fmt.Sprintf("guardrails: step increased from %s to %s to limit the results to %d points",
+			model.Duration(r.Step),
+			model.Duration(step),
+			g.MaxPoints,
+		))
+		r.Step = step
+	}
+
+	if len(notes) != 0 {
+		logger.Warn("Query clamped", "notes", notes)
+	}
+
+	return r, opts, notes, nil
+}
+
+// guardSeries is a method that applies the guardrails to a series request
+// It returns options (i.e. a limit) to apply to the request
+// One more than the budget is requested so that exceeding the budget can be detected
+func (x *Client) guardSeries() []v1.Option {
+	if x.guardrails.MaxSeries == 0 {
+		return nil
+	}
+	return []v1.Option{v1.WithLimit(x.guardrails.MaxSeries + 1)}
+}
+
+// checkSeries is a method that applies the guardrails to series results
+// It returns the (possibly truncated) results and notes describing any clamping
+func (x *Client) checkSeries(results []model.LabelSet, logger *slog.Logger) ([]model.LabelSet, []string, error) {
+	g := x.guardrails
+	if g.MaxSeries == 0 || uint64(len(results)) <= g.MaxSeries {
+		return results, nil, nil
+	}
+
+	if !g.Clamp {
+		msg := fmt.Sprintf("series matches more than %d series (--guardrails.max-series); narrow the 'match[]' selectors with more specific label matchers (e.g. {job=\"...\"}) or a shorter 'start' to 'end' range",
+			g.MaxSeries,
+		)
+		return nil, nil, errors.NewErrToolHandler(msg, nil)
+	}
+
+	logger.Warn("Series clamped", "max_series", g.MaxSeries)
+	note := fmt.Sprintf("guardrails: results limited to the first %d series", g.MaxSeries)
+	return results[:g.MaxSeries], []string{note}, nil
+}
+
+// minStep is a function that calculates the smallest (whole second) step for which a range query returns at most max points
+// It returns false if no step satisfies the budget i.e. the series alone exceed the budget
+func minStep(d time.Duration, series, maxPoints uint64) (time.Duration, bool) {
+	steps := maxPoints / series
+	if steps < 2 {
+		return 0, false
+	}
+
+	// steps = d/step + 1 so step = d/(steps-1) rounded up
+	n := time.Duration(steps - 1)
+	step := (d + n - 1) / n
+
+	// Round up to a whole second
+	if r := step % time.Second; r != 0 {
+		step += time.Second - r
+	}
+
+	return step, true
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/testdata"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestQueryRangeGuardrails tests that QueryRange rejects|clamps expensive queries
+func TestQueryRangeGuardrails(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// series requests estimate 5000 series (or the requested limit if less)
+	// Estimates must be limited so that these aren't themselves expensive
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(r.FormValue("limit"))
+		if err != nil || limit == 0 {
+			http.Error(w, "expected limit", http.StatusBadRequest)
+			return
+		}
+		n := min(5000, limit)
+
+		series := make([]string, n)
+		for i := range series {
+			series[i] = fmt.Sprintf(`{"__name__":"up","instance":"%d"}`, i)
+		}
+		resp := fmt.Sprintf(`{"data":[%s],"status":"success"}`, strings.Join(series, ","))
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	// Record the step (and limit) received by Prometheus
+	var step, limit string
+	mux.HandleFunc("/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		step = r.FormValue("step")
+		limit = r.FormValue("limit")
+
+		resp := `{"data":{"resultType":"matrix","result":[]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	end := testdata.Timestamp
+	tests := []struct {
+		name       string
+		guardrails config.Guardrails
+		start      time.Time
+		step       string
+		// Expected error substring ("" if no error)
+		err string
+		// Expected step and limit received by Prometheus
+		wantStep  string
+		wantLimit string
+	}{
+		{
+			name:       "disabled",
+			guardrails: config.Guardrails{},
+			start:      end.Add(-30 * 24 * time.Hour),
+			step:       "1s",
+			wantStep:   "1",
+		},
+		{
+			name:       "within budget",
+			guardrails: config.Guardrails{MaxSeries: 10000, MaxPoints: 1000000},
+			start:      end.Add(-time.Hour),
+			step:       "1m",
+			wantStep:   "60",
+		},
+		{
+			name:       "reject points",
+			guardrails: config.Guardrails{MaxSeries: 10000, MaxPoints: 1000000},
+			start:      end.Add(-30 * 24 * time.Hour),
+			step:       "1s",
+			err:        "increase 'step' to at least 3h37m6s",
+		},
+		{
+			name:       "reject series",
+			guardrails: config.Guardrails{MaxSeries: 1000},
+			start:      end.Add(-time.Hour),
+			step:       "1m",
+			err:        "selects more than 1000 series",
+		},
+		{
+			name:       "reject range",
+			guardrails: config.Guardrails{MaxRange: 24 * time.Hour},
+			start:      end.Add(-30 * 24 * time.Hour),
+			step:       "1h",
+			err:        "exceeds the budget of 1d",
+		},
+		{
+			name:       "clamp points",
+			guardrails: config.Guardrails{MaxSeries: 10000, MaxPoints: 1000000, Clamp: true},
+			start:      end.Add(-30 * 24 * time.Hour),
+			step:       "1s",
+			wantStep:   "13026",
+		},
+		{
+			name:       "clamp series",
+			guardrails: config.Guardrails{MaxSeries: 1000, Clamp: true},
+			start:      end.Add(-time.Hour),
+			step:       "1m",
+			wantStep:   "60",
+			wantLimit:  "1000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, limit = "", ""
+
+			c := NewClient(apiClient, logger, WithGuardrails(test.guardrails))
+
+			rqst := mcp.CallToolRequest{
+				Request: mcp.Request{
+					Method: "tools/call",
+				},
+				Params: mcp.CallToolParams{
+					Name: "QueryRange",
+					Arguments: map[string]any{
+						"query": `up{job="prometheus"}`,
+						"start": test.start.Format(time.RFC3339),
+						"end":   end.Format(time.RFC3339),
+						"step":  test.step,
+					},
+				},
+			}
+			resp, err := c.QueryRange(context.Background(), rqst)
+
+			if test.err != "" {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				t.Logf("Error: %+v", err)
+				if !resp.IsError {
+					t.Errorf("expected error result")
+				}
+				if text := resp.Content[0].(mcp.TextContent).Text; !strings.Contains(text, test.err) {
+					t.Errorf("got: %s; want: %s", text, test.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to invoke QueryRange method: %+v", err)
+			}
+
+			t.Logf("Response: %+v", resp)
+
+			if step != test.wantStep {
+				t.Errorf("step: got: %q; want: %q", step, test.wantStep)
+			}
+			if limit != test.wantLimit {
+				t.Errorf("limit: got: %q; want: %q", limit, test.wantLimit)
+			}
+
+			// Clamping is reported as additional content
+			if test.guardrails.Clamp && note(resp, "guardrails:") == "" {
+				t.Errorf("expected clamping note")
+			}
+		})
+	}
+}
+
+// TestSeriesGuardrails tests that Series rejects|clamps results that exceed the budget
+func TestSeriesGuardrails(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var limit string
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		limit = r.FormValue("limit")
+
+		data := testdata.JsonSeries
+		resp := fmt.Sprintf(`{"data":%s,"status":"success"}`, data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "Series",
+			Arguments: map[string]any{
+				"match[]": []any{"up"},
+			},
+		},
+	}
+
+	{
+		c := NewClient(apiClient, logger, WithGuardrails(config.Guardrails{MaxSeries: 1}))
+		resp, err := c.Series(context.Background(), rqst)
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if !resp.IsError {
+			t.Errorf("expected error result")
+		}
+		if limit != "2" {
+			t.Errorf("limit: got: %q; want: %q", limit, "2")
+		}
+	}
+	{
+		c := NewClient(apiClient, logger, WithGuardrails(config.Guardrails{MaxSeries: 1, Clamp: true}))
+		resp, err := c.Series(context.Background(), rqst)
+		if err != nil {
+			t.Fatalf("unable to invoke Series method: %+v", err)
+		}
+
+		t.Logf("Response: %+v", resp)
+
+		if note(resp, "guardrails:") == "" {
+			t.Fatalf("expected clamping note")
+		}
+
+		got := resp.Content[0].(mcp.TextContent).Text
+		want := string(testdata.MustMarshal(testdata.Series[:1]))
+		if got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+// TestMinStep tests minStep
+func TestMinStep(t *testing.T) {
+	tests := []struct {
+		d         time.Duration
+		series    uint64
+		maxPoints uint64
+		want      time.Duration
+		ok        bool
+	}{
+		{d: time.Hour, series: 1, maxPoints: 61, want: time.Minute, ok: true},
+		{d: time.Hour, series: 10, maxPoints: 610, want: time.Minute, ok: true},
+		{d: time.Hour, series: 1, maxPoints: 60, want: 62 * time.Second, ok: true},
+		{d: time.Hour, series: 10, maxPoints: 19, ok: false},
+	}
+	for _, test := range tests {
+		got, ok := minStep(test.d, test.series, test.maxPoints)
+		if ok != test.ok || got != test.want {
+			t.Errorf("minStep(%s, %d, %d): got: %s, %t; want: %s, %t", test.d, test.series, test.maxPoints, got, ok, test.want, test.ok)
+		}
+	}
+}
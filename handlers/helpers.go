@@ -1,11 +1,21 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/render"
+	"github.com/mark3labs/mcp-go/mcp"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
 )
 
 // extractOptions is a function that extracts optional parameters (timeout|limit) from the arguments
@@ -22,20 +32,57 @@ func extractOptions(args map[string]any, logger *slog.Logger) ([]v1.Option, erro
 		opts = append(opts, v1.WithTimeout(timeout))
 	}
 
-	if s, ok := args["limit"]; ok {
-		limit, ok := s.(uint64)
-		if ok {
-			opts = append(opts, v1.WithLimit(limit))
-		} else {
-			// The value is optional so, log but continue...
-			msg := "unable to parse limit"
-			logger.Info(msg)
-		}
+	// JSON numbers are decoded as float64
+	limit, err := extractLimit(args["limit"], logger)
+	if err != nil {
+		return opts, err
+	}
+	if limit != 0 {
+		opts = append(opts, v1.WithLimit(limit))
 	}
 
 	return opts, nil
 }
 
+// extractLimit is a function that extracts a (non-negative) limit from an argument
+// JSON numbers are decoded as float64 but, for convenience, integer types are also accepted
+// A missing limit is represented by 0
+func extractLimit(x any, logger *slog.Logger) (uint64, error) {
+	switch v := x.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			msg := "limit must be a non-negative integer"
+			logger.Error(msg, "limit", v)
+			return 0, errors.NewErrToolHandler(msg, nil)
+		}
+		return uint64(v), nil
+	case int:
+		if v < 0 {
+			msg := "limit must be a non-negative integer"
+			logger.Error(msg, "limit", v)
+			return 0, errors.NewErrToolHandler(msg, nil)
+		}
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	default:
+		msg := "unable to parse limit"
+		logger.Error(msg, "limit", v)
+		return 0, errors.NewErrToolHandler(msg, nil)
+	}
+}
+
+// formatLimit is a function that formats a limit as expected by Prometheus API methods that use strings
+// A limit of 0 is represented by "" (no limit)
+func formatLimit(limit uint64) string {
+	if limit == 0 {
+		return ""
+	}
+	return strconv.FormatUint(limit, 10)
+}
+
 // extractDuration is a function that extracts a time.Duration from an argument
 func extractDuration(x any, logger *slog.Logger) (time.Duration, error) {
 	var d time.Duration
@@ -54,12 +101,17 @@ func extractDuration(x any, logger *slog.Logger) (time.Duration, error) {
 }
 
 // extractTimestamp is a function that extracts a time.Time from an argument
-func extractTimestamp(x any, logger *slog.Logger) (time.Time, error) {
+// Timestamps may be RFC-3339, Unix epochs (seconds) or relative to now (see parseTime)
+func extractTimestamp(x any, now time.Time, logger *slog.Logger) (time.Time, error) {
 	var t time.Time
 
-	if s, ok := x.(string); ok {
+	switch v := x.(type) {
+	case float64:
+		// JSON numbers are decoded as float64
+		t = parseEpoch(v)
+	case string:
 		var err error
-		t, err = time.Parse(time.RFC3339, s)
+		t, err = parseTime(v, now)
 		if err != nil {
 			msg := "unable to parse time"
 			logger.Error(msg, "err", err)
@@ -70,6 +122,166 @@ func extractTimestamp(x any, logger *slog.Logger) (time.Time, error) {
 	return t, nil
 }
 
+// extractAbsoluteTimestamp is a function that extracts a time.Time from an argument
+// Unlike extractTimestamp, timestamps must be absolute (RFC-3339 or Unix epochs (seconds))
+// Relative times resolve to different times on different calls; these aren't accepted by tools whose calls must be repeatable
+func extractAbsoluteTimestamp(x any, logger *slog.Logger) (time.Time, error) {
+	if s, ok := x.(string); ok && s != "" {
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				msg := "unable to parse time"
+				err := fmt.Errorf("unable to parse %q as RFC-3339 or Unix epoch (relative times aren't accepted)", s)
+				logger.Error(msg, "err", err)
+				return time.Time{}, errors.NewErrToolHandler(msg, err)
+			}
+		}
+	}
+
+	return extractTimestamp(x, time.Now(), logger)
+}
+
+// extractStart is a function that extracts the start of a range from an argument
+// In addition to the timestamps accepted by extractTimestamp, start may be a duration (e.g. 1h) before end
+// If end is omitted (zero), the duration is before now
+func extractStart(x any, end, now time.Time, logger *slog.Logger) (time.Time, error) {
+	if s, ok := x.(string); ok {
+		if d, err := model.ParseDuration(s); err == nil && d > 0 {
+			if end.IsZero() {
+				end = now
+			}
+			return end.Add(-time.Duration(d)), nil
+		}
+	}
+
+	return extractTimestamp(x, now, logger)
+}
+
+var (
+	// relative matches Grafana-style relative times e.g. now, now-1h, -30m, now/d, now-1d/d
+	relative = regexp.MustCompile(`^(now)?((?:[+-]\d+(?:ms|[smhdwMy]))*)(?:/([smhdwMy]))?$`)
+	// offset matches each offset in a relative time e.g. -1h
+	offset = regexp.MustCompile(`([+-])(\d+)(ms|[smhdwMy])`)
+)
+
+// parseTime is a function that parses a timestamp relative to now
+// It accepts:
+// RFC-3339 e.g. 2025-06-13T10:00:00Z
+// Unix epochs (seconds) e.g. 1749808800 or 1749808800.5
+// Relative times e.g. now, now-1h, -30m, now+5m, now-1h-30m
+// Grafana-style rounding (in UTC) e.g. now/d (start of today), now-1d/d (start of yesterday)
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return parseEpoch(f), nil
+	}
+
+	// Relative times must reference now or begin with an offset
+	m := relative.FindStringSubmatch(s)
+	if m == nil || (m[1] == "" && m[2] == "") {
+		return time.Time{}, fmt.Errorf("unable to parse %q as RFC-3339, Unix epoch or relative time (e.g. now-1h, -30m, now/d)", s)
+	}
+
+	t := now.UTC()
+	for _, o := range offset.FindAllStringSubmatch(m[2], -1) {
+		n, err := strconv.Atoi(o[2])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if o[1] == "-" {
+			n = -n
+		}
+		t = addOffset(t, n, o[3])
+	}
+
+	if m[3] != "" {
+		t = round(t, m[3])
+	}
+
+	return t, nil
+}
+
+// parseEpoch is a function that converts a Unix epoch (seconds) into a time.Time
+func parseEpoch(f float64) time.Time {
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+// addOffset is a function that adds n units to a time
+// Months and years are calendar months and years
+func addOffset(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "ms":
+		return t.Add(time.Duration(n) * time.Millisecond)
+	case "s":
+		return t.Add(time.Duration(n) * time.Second)
+	case "m":
+		return t.Add(time.Duration(n) * time.Minute)
+	case "h":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, n)
+	case "w":
+		return t.AddDate(0, 0, 7*n)
+	case "M":
+		return t.AddDate(0, n, 0)
+	case "y":
+		return t.AddDate(n, 0, 0)
+	}
+	return t
+}
+
+// round is a function that rounds a time down to the start of a unit
+// Weeks start on Monday
+func round(t time.Time, unit string) time.Time {
+	year, month, day := t.Date()
+	switch unit {
+	case "s":
+		return t.Truncate(time.Second)
+	case "m":
+		return t.Truncate(time.Minute)
+	case "h":
+		return t.Truncate(time.Hour)
+	case "d":
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	case "w":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, t.Location())
+	case "M":
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case "y":
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// resolvedNotes is a function that echoes the absolute start and end times used by a tool
+// This removes any ambiguity from relative times (e.g. now-1h); omitted times are excluded
+func resolvedNotes(start, end time.Time) []string {
+	ss := []string{}
+	if !start.IsZero() {
+		ss = append(ss, "start="+formatTimestamp(start))
+	}
+	if !end.IsZero() {
+		ss = append(ss, "end="+formatTimestamp(end))
+	}
+	if len(ss) == 0 {
+		return nil
+	}
+	return []string{"resolved: " + strings.Join(ss, " ")}
+}
+
+// formatTimestamp is a function that formats optional timestamps
+// The zero time.Time represents an omitted timestamp and is formatted as ""
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
 // extractMatches is a function that extracts recurring "match[]" from an argument
 func extractMatches(x any, logger *slog.Logger) ([]string, error) {
 	if mm, ok := x.([]any); !ok {
@@ -88,3 +300,78 @@ func extractMatches(x any, logger *slog.Logger) ([]string, error) {
 		return matches, nil
 	}
 }
+
+// extractStrings is a function that extracts a list of strings from a (named) argument
+func extractStrings(x any, name string, logger *slog.Logger) ([]string, error) {
+	xx, ok := x.([]any)
+	if !ok {
+		msg := fmt.Sprintf("unable to extract '%s' parameter", name)
+		logger.Info(msg)
+		return nil, errors.NewErrToolHandler(msg, nil)
+	}
+
+	ss := make([]string, len(xx))
+	for i, v := range xx {
+		if ss[i], ok = v.(string); !ok {
+			msg := fmt.Sprintf("unable to convert a '%s' parameter", name)
+			logger.Info(msg, name, v)
+			return nil, errors.NewErrToolHandler(msg, nil)
+		}
+	}
+
+	return ss, nil
+}
+
+// parseConfigSections is a function that parses Prometheus' YAML configuration
+// It returns only the requested top-level sections (e.g. scrape_configs)
+func parseConfigSections(s string, sections []string, logger *slog.Logger) (map[string]any, error) {
+	config := map[string]any{}
+	if err := yaml.Unmarshal([]byte(s), &config); err != nil {
+		msg := "unable to unmarshal configuration"
+		logger.Error(msg, "err", err)
+		return nil, errors.NewErrToolHandler(msg, err)
+	}
+
+	// Prometheus omits empty sections (e.g. remote_write) and these are returned as null
+	result := make(map[string]any, len(sections))
+	for _, section := range sections {
+		result[section] = config[section]
+	}
+
+	return result, nil
+}
+
+// withNotes is a function that appends notes (e.g. guardrails clamping) to a tool result
+// Notes are appended as additional text content so that the first content remains the result
+func withNotes(result *mcp.CallToolResult, notes []string) *mcp.CallToolResult {
+	for _, note := range notes {
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	return result
+}
+
+// extractOutput is a function that extracts an output format from an argument
+// If omitted, the output format is JSON
+func extractOutput(x any, logger *slog.Logger) (render.Format, error) {
+	s, _ := x.(string)
+	format, err := render.ParseFormat(s)
+	if err != nil {
+		msg := "unable to parse output format"
+		logger.Error(msg, "err", err)
+		return format, errors.NewErrToolHandler(msg, err)
+	}
+	return format, nil
+}
+
+// output is a function that renders results as JSON or, for other formats, as the tabular Result returned by f
+func output(format render.Format, v any, f func() *render.Result) (string, error) {
+	if format == render.JSON {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	return f().Render(format)
+}
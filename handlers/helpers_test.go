@@ -3,8 +3,11 @@ package handlers
 import (
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // TestExtractOptions tests extractOptions
@@ -25,6 +28,58 @@ func TestExtractOptions(t *testing.T) {
 	}
 
 	// Can't compare []v1.Option easily
+
+	// JSON numbers are decoded as float64
+	args["limit"] = float64(limit)
+	opts, err := extractOptions(args, logger)
+	if err != nil {
+		t.Fatal("expected success")
+	}
+	if len(opts) != 2 {
+		t.Errorf("got: %d options; want: 2", len(opts))
+	}
+
+	args["limit"] = float64(-1)
+	if _, err := extractOptions(args, logger); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestExtractLimit tests extractLimit
+func TestExtractLimit(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	tests := []struct {
+		name  string
+		x     any
+		want  uint64
+		isErr bool
+	}{
+		{name: "nil", x: nil, want: 0},
+		{name: "float64", x: float64(10), want: 10},
+		{name: "int", x: 10, want: 10},
+		{name: "uint64", x: uint64(10), want: 10},
+		{name: "negative", x: float64(-1), isErr: true},
+		{name: "fractional", x: float64(1.5), isErr: true},
+		{name: "string", x: "10", isErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := extractLimit(test.x, logger)
+			if test.isErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected success: %q", err)
+			}
+			if got != test.want {
+				t.Errorf("got: %d, want: %d", got, test.want)
+			}
+		})
+	}
 }
 
 // TestExtractDuration tests extractDuration
@@ -52,7 +107,7 @@ func TestExtractTimestamp(t *testing.T) {
 
 	want := time.Now().Format(time.RFC3339)
 
-	ts, err := extractTimestamp(want, logger)
+	ts, err := extractTimestamp(want, time.Now(), logger)
 	if err != nil {
 		t.Fatal("expected success")
 	}
@@ -94,3 +149,121 @@ func TestExtractMatches(t *testing.T) {
 		}
 	}
 }
+
+// TestExtractStrings tests extractStrings
+func TestExtractStrings(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// Test invalid type
+	if _, err := extractStrings("foo", "sections", logger); err == nil {
+		t.Error("expected error")
+	}
+
+	// Test valid initial type but doesn't assert to []string
+	if _, err := extractStrings([]any{"foo", 1}, "sections", logger); err == nil {
+		t.Error("expected error")
+	}
+
+	// Test valid initial type that asserts to []string
+	if _, err := extractStrings([]any{"global", "scrape_configs"}, "sections", logger); err != nil {
+		t.Errorf("expected success: %q", err)
+	}
+}
+
+// note is a function that returns the (first) note (additional text content) of a tool result with a prefix
+// If the result has no such note, it returns ""
+func note(resp *mcp.CallToolResult, prefix string) string {
+	for _, content := range resp.Content[1:] {
+		if text, ok := content.(mcp.TextContent); ok && strings.HasPrefix(text.Text, prefix) {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// TestParseTime tests parseTime
+func TestParseTime(t *testing.T) {
+	// Friday
+	now := time.Date(2025, time.June, 13, 10, 30, 15, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"2025-06-13T10:00:00Z":      time.Date(2025, time.June, 13, 10, 0, 0, 0, time.UTC),
+		"2025-06-13T10:00:00-07:00": time.Date(2025, time.June, 13, 17, 0, 0, 0, time.UTC),
+		"1749808800":                time.Date(2025, time.June, 13, 10, 0, 0, 0, time.UTC),
+		"1749808800.5":              time.Date(2025, time.June, 13, 10, 0, 0, 500000000, time.UTC),
+		"now":                       now,
+		"now-1h":                    now.Add(-time.Hour),
+		"now+5m":                    now.Add(5 * time.Minute),
+		"now-1h-30m":                now.Add(-90 * time.Minute),
+		"-30m":                      now.Add(-30 * time.Minute),
+		"now-1d":                    now.AddDate(0, 0, -1),
+		"now-1M":                    now.AddDate(0, -1, 0),
+		"now/h":                     time.Date(2025, time.June, 13, 10, 0, 0, 0, time.UTC),
+		"now/d":                     time.Date(2025, time.June, 13, 0, 0, 0, 0, time.UTC),
+		"now-1d/d":                  time.Date(2025, time.June, 12, 0, 0, 0, 0, time.UTC),
+		"now/w":                     time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC),
+		"now/M":                     time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		"now/y":                     time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		got, err := parseTime(s, now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %+v", s, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: got: %s; want: %s", s, got, want)
+		}
+	}
+
+	for _, s := range []string{"", "yesterday", "now-1x", "1h", "now/x"} {
+		if _, err := parseTime(s, now); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+// TestExtractStart tests extractStart
+func TestExtractStart(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	end := time.Date(2025, time.June, 13, 10, 0, 0, 0, time.UTC)
+
+	// Durations are before end
+	got, err := extractStart("1h", end, time.Now(), logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if want := end.Add(-time.Hour); !got.Equal(want) {
+		t.Errorf("got: %s; want: %s", got, want)
+	}
+
+	// Timestamps are unaffected by end
+	got, err = extractStart("2025-06-13T09:00:00Z", end, time.Now(), logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if want := end.Add(-time.Hour); !got.Equal(want) {
+		t.Errorf("got: %s; want: %s", got, want)
+	}
+
+	// Epochs may be JSON numbers
+	got, err = extractStart(float64(1749808800), end, time.Now(), logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if !got.Equal(end) {
+		t.Errorf("got: %s; want: %s", got, end)
+	}
+
+	// If end is omitted, durations and relative times are before now
+	for _, start := range []string{"1h", "now-1h"} {
+		got, err = extractStart(start, time.Time{}, end, logger)
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if want := end.Add(-time.Hour); !got.Equal(want) {
+			t.Errorf("%s: got: %s; want: %s", start, got, want)
+		}
+	}
+}
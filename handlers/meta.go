@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 
-	"github.com/DazWilkin/prometheus-mcp-server/errors"
 	"github.com/DazWilkin/prometheus-mcp-server/management"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -17,16 +17,50 @@ import (
 // Meta is a type that represents Prometheus Management API
 type Meta struct {
 	client *management.Client
-	logger *slog.Logger
+	// writes enables the Management API methods (reload, quit) that change Prometheus' state
+	writes bool
+	// clientOpts configure the Management API client
+	clientOpts []management.ClientOption
+	logger     *slog.Logger
+}
+
+// MetaOption is a type that represents an optional configuration of Meta
+type MetaOption func(*Meta)
+
+// WithManagementWrites is a function that enables|disables the Management API methods that change Prometheus' state
+func WithManagementWrites(writes bool) MetaOption {
+	return func(x *Meta) {
+		x.writes = writes
+	}
+}
+
+// WithRoundTripper is a function that configures the http.RoundTripper used by the Management API client
+// This should be the RoundTripper used by the Prometheus API client so that requests are authenticated consistently
+func WithRoundTripper(rt http.RoundTripper) MetaOption {
+	return func(x *Meta) {
+		x.clientOpts = append(x.clientOpts, management.WithRoundTripper(rt))
+	}
+}
+
+// WithReadyPath is a function that configures the path of the readiness check used by ping e.g. /ready for Mimir and Cortex
+func WithReadyPath(path string) MetaOption {
+	return func(x *Meta) {
+		if path != "" {
+			x.clientOpts = append(x.clientOpts, management.WithReadyPath(path))
+		}
+	}
 }
 
 // NewMeta is a function that creates a new Meta
-func NewMeta(prometheus string, logger *slog.Logger) *Meta {
-	client := management.NewClient(prometheus, logger)
-	return &Meta{
-		client: client,
+func NewMeta(prometheus string, logger *slog.Logger, opts ...MetaOption) *Meta {
+	x := &Meta{
 		logger: logger,
 	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	x.client = management.NewClient(prometheus, logger, x.clientOpts...)
+	return x
 }
 
 // Tools is a method that returns the MCP server tools implemented by Meta
@@ -38,6 +72,13 @@ func (x *Meta) Tools() []server.ServerTool {
 	defer logger.Debug("Exited")
 
 	tools := []server.ServerTool{
+		{
+			Tool: mcp.NewTool(
+				"healthy",
+				mcp.WithDescription("Check the health of the Prometheus server"),
+			),
+			Handler: x.Healthy,
+		},
 		{
 			Tool: mcp.NewTool(
 				"ping",
@@ -47,29 +88,105 @@ func (x *Meta) Tools() []server.ServerTool {
 		},
 	}
 
+	// Management API methods that change Prometheus' state are only published when explicitly enabled
+	if x.writes {
+		logger.Info("Management API writes enabled")
+		tools = append(tools,
+			server.ServerTool{
+				Tool: mcp.NewTool(
+					"quit",
+					mcp.WithDescription("Trigger a graceful shutdown of the Prometheus server (requires --web.enable-lifecycle)"),
+					mcp.WithDestructiveHintAnnotation(true),
+				),
+				Handler: x.Quit,
+			},
+			server.ServerTool{
+				Tool: mcp.NewTool(
+					"reload",
+					mcp.WithDescription("Trigger a reload of the Prometheus configuration and rule files (requires --web.enable-lifecycle)"),
+					mcp.WithDestructiveHintAnnotation(false),
+				),
+				Handler: x.Reload,
+			},
+		)
+	}
+
 	return tools
 }
 
-// Ping is a method that pings the Prometheus server's Management API's Readiness check
-func (x *Meta) Ping(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	method := "Ping"
-	logger := x.logger.With("method", method)
-	logger.Debug("Entered")
-	defer logger.Debug("Exited")
-
+// do is a method that invokes a Prometheus Management API method and converts its response into a tool result
+func (x *Meta) do(ctx context.Context, method string, f func(context.Context) (int, string, error), logger *slog.Logger) (*mcp.CallToolResult, error) {
 	// Increment Prometheus total metric
 	totalx.With(prometheus.Labels{
 		"tool": method,
 	}).Inc()
 
-	// Invoke Prometheus Management Ready method
-	respCode := x.client.Ready()
+	respCode, body, err := f(ctx)
+	if err != nil {
+		msg := "unable to invoke Prometheus Management API"
+		return Err(method, msg, err, logger)
+	}
 
 	// Expect 200
+	// Otherwise include the response body since it explains the failure e.g. "Lifecycle API is not enabled."
 	if respCode != http.StatusOK {
-		msg := "serv"
-		return mcp.NewToolResultError(msg), errors.NewErrToolHandler(msg, nil)
+		msg := fmt.Sprintf("Prometheus Management API responded %d (%s): %s",
+			respCode,
+			http.StatusText(respCode),
+			body,
+		)
+		return Err(method, msg, nil, logger)
+	}
+
+	// Some methods (e.g. reload) succeed with an empty body
+	if body == "" {
+		body = "OK"
 	}
 
-	return mcp.NewToolResultText("OK"), nil
+	return mcp.NewToolResultText(body), nil
+}
+
+// Healthy is a method that checks the Prometheus server's Management API's Health check
+func (x *Meta) Healthy(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Healthy"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Invoke Prometheus Management Healthy method
+	return x.do(ctx, method, x.client.Healthy, logger)
+}
+
+// Ping is a method that pings the Prometheus server's Management API's Readiness check
+// Mimir and Cortex use a backend-specific readiness path (see WithReadyPath)
+func (x *Meta) Ping(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Ping"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Invoke Prometheus Management Ready method
+	return x.do(ctx, method, x.client.Ready, logger)
+}
+
+// Quit is a method that triggers a graceful shutdown of the Prometheus server using the Management API
+func (x *Meta) Quit(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Quit"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Invoke Prometheus Management Quit method
+	return x.do(ctx, method, x.client.Quit, logger)
+}
+
+// Reload is a method that triggers a reload of the Prometheus server's configuration using the Management API
+func (x *Meta) Reload(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "Reload"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Invoke Prometheus Management Reload method
+	return x.do(ctx, method, x.client.Reload, logger)
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestMetaToolsWrites tests that Management API tools that change state are only published when enabled
+func TestMetaToolsWrites(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	names := func(m *Meta) []string {
+		nn := []string{}
+		for _, tool := range m.Tools() {
+			nn = append(nn, tool.Tool.Name)
+		}
+		return nn
+	}
+
+	{
+		got := names(NewMeta(p, logger))
+		if slices.Contains(got, "reload") || slices.Contains(got, "quit") {
+			t.Errorf("expected no write tools: %+q", got)
+		}
+	}
+	{
+		got := names(NewMeta(p, logger, WithManagementWrites(true)))
+		if !slices.Contains(got, "reload") || !slices.Contains(got, "quit") {
+			t.Errorf("expected write tools: %+q", got)
+		}
+	}
+}
+
+// TestReload tests Reload
+// https://prometheus.io/docs/prometheus/latest/management_api/#reload
+func TestReload(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Mimic Prometheus when it is not started with --web.enable-lifecycle
+	want := "Lifecycle API is not enabled."
+	mux.HandleFunc("POST /-/reload", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, want, http.StatusForbidden)
+	})
+
+	m := NewMeta(server.URL, logger, WithManagementWrites(true))
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name:      "Reload",
+			Arguments: map[string]any{},
+		},
+	}
+	resp, err := m.Reload(context.Background(), rqst)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	if !resp.IsError {
+		t.Errorf("expected error result")
+	}
+
+	content := resp.Content[0].(mcp.TextContent)
+	if !strings.Contains(content.Text, want) {
+		t.Errorf("got:\n%s\nwant (contains):\n%s", content.Text, want)
+	}
+}
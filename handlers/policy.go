@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
+	"github.com/DazWilkin/prometheus-mcp-server/policy"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithPolicy is a function that configures the policy that determines the tools and series that principals may access
+func WithPolicy(p *policy.Policy) DatasourcesOption {
+	return func(x *Datasources) {
+		x.policy = p
+	}
+}
+
+// enforce is a method that wraps a tool's handler so that calls are permitted by the policy
+// Calls by principals to which no rule applies or of tools that the rule doesn't allow are rejected
+// The rule's label matchers are added to the calls' PromQL expressions and series selectors
+func (x *Datasources) enforce(tool server.ServerTool) server.ServerTool {
+	if x.policy == nil {
+		return tool
+	}
+
+	name := tool.Tool.Name
+	handler := tool.Handler
+	tool.Handler = func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		method := rqst.Params.Name
+		logger := x.logger.With("method", method)
+
+		principal := "(unauthenticated)"
+		if p, ok := auth.PrincipalFrom(ctx); ok {
+			principal = p.Name
+		}
+
+		rule, ok := x.policy.Rule(ctx)
+		if !ok {
+			msg := fmt.Sprintf("policy: no policy applies to principal %q", principal)
+			return Err(method, msg, nil, logger)
+		}
+		if !rule.Allows(name) {
+			msg := fmt.Sprintf("policy: policy %q doesn't allow principal %q to call %q", rule.Name, principal, name)
+			return Err(method, msg, nil, logger)
+		}
+
+		matchers := rule.LabelMatchers()
+		if len(matchers) == 0 {
+			return handler(ctx, rqst)
+		}
+
+		// The arguments are copied since the request's arguments may be shared
+		args := maps.Clone(rqst.GetArguments())
+		if args == nil {
+			args = map[string]any{}
+		}
+		switch {
+		case slices.Contains(policy.Queries, name):
+			if query, ok := args["query"].(string); ok {
+				rewritten, err := promql.Inject(query, matchers)
+				if err != nil {
+					msg := fmt.Sprintf("invalid PromQL query: %s", err)
+					return Err(method, msg, err, logger)
+				}
+				args["query"] = rewritten
+			}
+		case slices.Contains(policy.Matches, name):
+			matches := []any{promql.MatchersSelector(matchers)}
+			if m, ok := args["match[]"]; ok {
+				ss, err := extractMatches(m, logger)
+				if err != nil {
+					msg := "unable to extract repeated 'match[]' parameters"
+					return Err(method, msg, err, logger)
+				}
+				matches = make([]any, len(ss))
+				for i, s := range ss {
+					rewritten, err := promql.Inject(s, matchers)
+					if err != nil {
+						msg := fmt.Sprintf("invalid series selector: %s", err)
+						return Err(method, msg, err, logger)
+					}
+					matches[i] = rewritten
+				}
+			}
+			args["match[]"] = matches
+		}
+		rqst.Params.Arguments = args
+
+		logger.Debug("Policy", "policy", rule.Name, "principal", principal, "matchers", promql.MatchersSelector(matchers))
+		return handler(ctx, rqst)
+	}
+
+	return tool
+}
+
+// enforceResource is a method that wraps a resource's handler so that reads are permitted by the policy
+func (x *Datasources) enforceResource(uri string, handler server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+	if x.policy == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		method := "resource"
+		logger := x.logger.With("method", method)
+
+		if msg, ok := x.permitsResource(ctx, uri, rqst.Params.URI); !ok {
+			return ErrResource(method, msg, nil, logger)
+		}
+
+		return handler(ctx, rqst)
+	}
+}
+
+// permitsResource is a method that returns whether the policy (if any) permits the principal of ctx to read (or subscribe to) a resource
+// Reads are permitted if the rule allows the resource's tools
+// Resources aren't restricted to series so reads by principals whose queries are restricted by label matchers are rejected
+// If not permitted, it returns the reason
+func (x *Datasources) permitsResource(ctx context.Context, uri, resource string) (string, bool) {
+	if x.policy == nil {
+		return "", true
+	}
+
+	principal := "(unauthenticated)"
+	if p, ok := auth.PrincipalFrom(ctx); ok {
+		principal = p.Name
+	}
+
+	rule, ok := x.policy.Rule(ctx)
+	if !ok {
+		return fmt.Sprintf("policy: no policy applies to principal %q", principal), false
+	}
+	for _, tool := range resourceTools[uri] {
+		if !rule.Allows(tool) {
+			return fmt.Sprintf("policy: policy %q doesn't allow principal %q to read %q", rule.Name, principal, resource), false
+		}
+		if len(rule.LabelMatchers()) != 0 && policy.Restricts(tool) {
+			return fmt.Sprintf("policy: policy %q restricts principal %q to series; use the %q tool rather than read %q", rule.Name, principal, tool, resource), false
+		}
+	}
+	return "", true
+}
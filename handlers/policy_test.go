@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
+	"github.com/DazWilkin/prometheus-mcp-server/policy"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestPolicy tests that calls are permitted by the policy and that its label matchers are added to queries and series selectors
+func TestPolicy(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// The server records the queries and series selectors that it receives
+	var mu sync.Mutex
+	received := []string{}
+	record := func(r *http.Request, key string) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unable to parse form: %+v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.Form[key]...)
+	}
+
+	mux := http.NewServeMux()
+	promServer := httptest.NewServer(mux)
+	defer promServer.Close()
+
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		record(r, "query")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	})
+	mux.HandleFunc("/api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		record(r, "match[]")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":[]}`))
+	})
+	mux.HandleFunc("/api/v1/labels", func(w http.ResponseWriter, r *http.Request) {
+		record(r, "match[]")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":[]}`))
+	})
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(`policies:
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - query
+  - series
+  - labels
+  - delete_series
+  matchers:
+  - namespace="team-a"
+`), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	p, err := policy.Load(path)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: promServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+	datasource := Datasource{
+		Name:   "default",
+		URL:    promServer.URL,
+		Client: NewClient(apiClient, logger, WithAdmin(true)),
+	}
+
+	tools := map[string]server.ServerTool{}
+	for _, tool := range NewDatasources([]Datasource{datasource}, logger, WithPolicy(p)).Tools() {
+		tools[tool.Tool.Name] = tool
+	}
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob", Groups: []string{"team-a"}})
+	mallory := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "mallory"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		tool string
+		args map[string]any
+		ok   bool
+		want []string
+	}{
+		{name: "query", ctx: bob, tool: "query", args: map[string]any{"query": `sum(up{job="api"})`}, ok: true, want: []string{`sum(up{job="api",namespace="team-a"})`}},
+		{name: "series", ctx: bob, tool: "series", args: map[string]any{"match[]": []any{"up", `{job="api"}`}}, ok: true, want: []string{`up{namespace="team-a"}`, `{job="api",namespace="team-a"}`}},
+		{name: "labels", ctx: bob, tool: "labels", args: map[string]any{}, ok: true, want: []string{`{namespace="team-a"}`}},
+		{name: "delete_series", ctx: bob, tool: "delete_series", args: map[string]any{"match[]": []any{`{job="api"}`}}, ok: true, want: []string{`{job="api",namespace="team-a"}`}},
+		{name: "tool not allowed", ctx: bob, tool: "targets", args: map[string]any{}},
+		{name: "no policy", ctx: mallory, tool: "query", args: map[string]any{"query": "up"}},
+		{name: "unauthenticated", ctx: context.Background(), tool: "query", args: map[string]any{"query": "up"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mu.Lock()
+			received = []string{}
+			mu.Unlock()
+
+			rqst := mcp.CallToolRequest{
+				Request: mcp.Request{
+					Method: "tools/call",
+				},
+				Params: mcp.CallToolParams{
+					Name:      test.tool,
+					Arguments: test.args,
+				},
+			}
+			resp, _ := tools[test.tool].Handler(test.ctx, rqst)
+			t.Logf("Response: %+v", resp)
+
+			if resp.IsError == test.ok {
+				t.Fatalf("got error: %t; want ok: %t", resp.IsError, test.ok)
+			}
+			if !test.ok {
+				if text := resp.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "policy:") {
+					t.Errorf("got: %s; want: policy error", text)
+				}
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, want := range test.want {
+				found := false
+				for _, got := range received {
+					found = found || got == want
+				}
+				if !found {
+					t.Errorf("got: %+q; want (contains): %q", received, want)
+				}
+			}
+		})
+	}
+}
+
+// TestPolicyResources tests that resource reads are permitted by the policy
+func TestPolicyResources(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	promServer := newResourcesServer(t)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(`policies:
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - labels
+  - validate_query
+  matchers:
+  - namespace="team-a"
+- name: sre
+  groups:
+  - sre
+  tools:
+  - "*"
+`), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	p, err := policy.Load(path)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: promServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+	d := NewDatasources([]Datasource{
+		{
+			Name:   "default",
+			URL:    promServer.URL,
+			Client: NewClient(apiClient, logger),
+		},
+	}, logger, WithPolicy(p))
+
+	handlers := map[string]server.ResourceHandlerFunc{}
+	for _, resource := range d.Resources() {
+		handlers[resource.Resource.URI] = resource.Handler
+	}
+	for _, template := range d.ResourceTemplates() {
+		handlers[template.Template.URITemplate.Raw()] = server.ResourceHandlerFunc(template.Handler)
+	}
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob", Groups: []string{"team-a"}})
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice", Groups: []string{"sre"}})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		resource string
+		args     map[string]any
+		ok       bool
+	}{
+		{name: "tool not allowed", ctx: bob, resource: "prometheus://rules/{group}", args: map[string]any{"group": []string{"node"}}},
+		{name: "restricted to series", ctx: bob, resource: "prometheus://metrics/{name}", args: map[string]any{"name": []string{"up"}}},
+		{name: "allowed", ctx: alice, resource: "prometheus://rules/{group}", args: map[string]any{"group": []string{"node"}}, ok: true},
+		{name: "unrestricted", ctx: alice, resource: "prometheus://metrics/{name}", args: map[string]any{"name": []string{"up"}}, ok: true},
+		{name: "unauthenticated", ctx: context.Background(), resource: "prometheus://config"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := mcp.ReadResourceRequest{}
+			rqst.Params.URI = test.resource
+			rqst.Params.Arguments = test.args
+
+			_, err := handlers[test.resource](test.ctx, rqst)
+			if (err == nil) != test.ok {
+				t.Fatalf("got: %v; want ok: %t", err, test.ok)
+			}
+			if !test.ok && !strings.HasPrefix(err.Error(), "policy:") {
+				t.Errorf("got: %v; want: policy error", err)
+			}
+		})
+	}
+}
+
+// TestPolicyPrompts tests that prompt gets are permitted by the policy
+func TestPolicyPrompts(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(`policies:
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - query
+  - query_range
+  matchers:
+  - namespace="team-a"
+- name: sre
+  groups:
+  - sre
+  tools:
+  - "*"
+`), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	p, err := policy.Load(path)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	d := NewDatasources(nil, logger, WithPolicy(p))
+
+	handlers := map[string]server.PromptHandlerFunc{}
+	for _, prompt := range d.Prompts([]Prompt{
+		newPrompt("cardinality_audit", "status_tsdb", "query"),
+		newPrompt("slo_burn", "query", "query_range"),
+	}) {
+		handlers[prompt.Prompt.Name] = prompt.Handler
+	}
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob", Groups: []string{"team-a"}})
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice", Groups: []string{"sre"}})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		prompt string
+		ok     bool
+	}{
+		{name: "tool not allowed", ctx: bob, prompt: "cardinality_audit"},
+		{name: "allowed", ctx: bob, prompt: "slo_burn", ok: true},
+		{name: "unrestricted", ctx: alice, prompt: "cardinality_audit", ok: true},
+		{name: "unauthenticated", ctx: context.Background(), prompt: "slo_burn"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := mcp.GetPromptRequest{}
+			rqst.Params.Name = test.prompt
+
+			_, err := handlers[test.prompt](test.ctx, rqst)
+			if (err == nil) != test.ok {
+				t.Fatalf("got: %v; want ok: %t", err, test.ok)
+			}
+			if !test.ok && !strings.HasPrefix(err.Error(), "policy:") {
+				t.Errorf("got: %v; want: policy error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prompt is a type that represents an MCP server prompt and the tools that its messages use
+type Prompt struct {
+	server.ServerPrompt
+	Tools []string
+}
+
+// ErrPrompt is a function that combines logging, metrics and returning prompt errors
+func ErrPrompt(method, msg string, err error, logger *slog.Logger) (*mcp.GetPromptResult, error) {
+	logger.Error(msg, "err", err)
+
+	// Increment Prometheus error metric
+	errorx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	return nil, errors.NewErrToolHandler(msg, err)
+}
+
+// Prompts is a method that returns the MCP server prompts
+// Only the prompts whose tools are published (see WithTools) are published
+func (x *Datasources) Prompts(prompts []Prompt) []server.ServerPrompt {
+	published := []server.ServerPrompt{}
+	for _, prompt := range prompts {
+		if !x.publishesPrompt(prompt.Tools) {
+			x.logger.Info("Prompt's tools aren't published", "prompt", prompt.Prompt.Name, "tools", prompt.Tools)
+			continue
+		}
+		prompt.Handler = x.enforcePrompt(prompt.Tools, prompt.Handler)
+		published = append(published, prompt.ServerPrompt)
+	}
+	return published
+}
+
+// publishesPrompt is a method that returns whether a prompt that uses the tools is published
+func (x *Datasources) publishesPrompt(tools []string) bool {
+	for _, tool := range tools {
+		if !x.publishes(tool) {
+			return false
+		}
+	}
+	return true
+}
+
+// enforcePrompt is a method that wraps a prompt's handler so that gets are permitted by the policy
+// Gets are permitted if the rule allows the prompt's tools
+func (x *Datasources) enforcePrompt(tools []string, handler server.PromptHandlerFunc) server.PromptHandlerFunc {
+	if x.policy == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, rqst mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		method := "prompt"
+		logger := x.logger.With("method", method)
+
+		principal := "(unauthenticated)"
+		if p, ok := auth.PrincipalFrom(ctx); ok {
+			principal = p.Name
+		}
+
+		rule, ok := x.policy.Rule(ctx)
+		if !ok {
+			msg := fmt.Sprintf("policy: no policy applies to principal %q", principal)
+			return ErrPrompt(method, msg, nil, logger)
+		}
+		for _, tool := range tools {
+			if !rule.Allows(tool) {
+				msg := fmt.Sprintf("policy: policy %q doesn't allow principal %q to call %q used by prompt %q", rule.Name, principal, tool, rqst.Params.Name)
+				return ErrPrompt(method, msg, nil, logger)
+			}
+		}
+
+		return handler(ctx, rqst)
+	}
+}
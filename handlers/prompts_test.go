@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newPrompt is a function that returns a prompt that uses the tools
+func newPrompt(name string, tools ...string) Prompt {
+	return Prompt{
+		ServerPrompt: server.ServerPrompt{
+			Prompt: mcp.NewPrompt(name),
+			Handler: func(ctx context.Context, rqst mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return mcp.NewGetPromptResult(name, nil), nil
+			},
+		},
+		Tools: tools,
+	}
+}
+
+// TestPrompts tests that prompts are only published if their tools are published
+func TestPrompts(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	prompts := []Prompt{
+		newPrompt("cardinality_audit", "status_tsdb", "query"),
+		newPrompt("explain_metric", "metrics", "metadata", "targets_metadata"),
+		newPrompt("check_target_health", "targets", "query"),
+		newPrompt("runbook"),
+	}
+
+	tests := []struct {
+		name  string
+		tools config.Tools
+		want  []string
+	}{
+		{name: "all", want: []string{"cardinality_audit", "check_target_health", "explain_metric", "runbook"}},
+		{name: "allow", tools: config.Tools{Allow: config.Names{"queries", "metrics", "metadata", "targets_metadata"}}, want: []string{"explain_metric", "runbook"}},
+		{name: "deny", tools: config.Tools{Deny: config.Names{"status"}}, want: []string{"check_target_health", "explain_metric", "runbook"}},
+		{name: "deny tool", tools: config.Tools{Deny: config.Names{"targets_metadata"}}, want: []string{"cardinality_audit", "check_target_health", "runbook"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewDatasources(nil, logger, WithTools(test.tools))
+
+			got := []string{}
+			for _, prompt := range d.Prompts(slices.Clone(prompts)) {
+				got = append(got, prompt.Prompt.Name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got: %v; want: %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// datasourceLabel is the label that identifies the datasource of query_all's series
+	datasourceLabel model.LabelName = "datasource"
+	// defaultConcurrency is the default maximum number of datasources queried concurrently
+	defaultConcurrency int = 4
+)
+
+// WithQueryAll is a function that configures queries of every datasource
+func WithQueryAll(queryAll config.QueryAll) DatasourcesOption {
+	return func(x *Datasources) {
+		x.queryAll = queryAll
+	}
+}
+
+// datasourceResult is a type that represents a datasource's query results
+type datasourceResult struct {
+	value model.Value
+	notes []string
+	err   *errors.ErrToolHandler
+}
+
+// QueryAll is a method that queries every datasource concurrently and merges the results
+// Series are labeled by datasource and datasources that fail are reported rather than failing the query
+func (x *Datasources) QueryAll(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	method := "QueryAll"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Tool provides arguments; retrieve these
+	// required: query
+	// optional: time, timeout, limit, output, cursor
+	args := rqst.GetArguments()
+
+	// Required
+	query := args["query"].(string)
+
+	// Validate the query locally before sending it to every datasource
+	if v := promql.Validate(query); !v.Valid {
+		msg := fmt.Sprintf("invalid PromQL query: %s", v.Err())
+		return Err(method, msg, v.Err(), logger)
+	}
+
+	// Optional
+	// Results are paged; cursor identifies the page
+	// Omitted and relative times are resolved from the time of the first page so that every page is of the same results
+	c, err := extractCursor(args["cursor"], nowFrom(ctx), logger)
+	if err != nil {
+		msg := "unable to extract 'cursor' parameter"
+		return Err(method, msg, err, logger)
+	}
+	now := c.now
+
+	// Optional
+	// Every datasource is queried at the same time; if omitted, now
+	ts, err := extractTimestamp(args["time"], now, logger)
+	if err != nil {
+		msg := "unable to extract 'time' parameter"
+		return Err(method, msg, err, logger)
+	}
+	if ts.IsZero() {
+		ts = now
+	}
+	notes := []string{fmt.Sprintf("resolved: time=%s", formatTimestamp(ts))}
+
+	// Optional
+	format, err := extractOutput(args["output"], logger)
+	if err != nil {
+		msg := "unable to extract 'output' parameter"
+		return Err(method, msg, err, logger)
+	}
+
+	// Optional
+	// Optional for Prometheus API method: timeout,limit
+	opts, err := extractOptions(args, logger)
+	if err != nil {
+		msg := "unable to extract optional arguments"
+		return Err(method, msg, err, logger)
+	}
+
+	// Query datasources concurrently (bounded by the configured concurrency) each with its own timeout
+	results := make([]datasourceResult, len(x.datasources))
+	sem := make(chan struct{}, max(1, x.queryAll.Concurrency))
+	var wg sync.WaitGroup
+	for i, d := range x.datasources {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			ctx := ctx
+			if x.queryAll.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, x.queryAll.Timeout)
+				defer cancel()
+			}
+
+			logger := logger.With("datasource", d.Name)
+			results[i].value, results[i].notes, results[i].err = d.Client.query(ctx, query, ts, opts, logger)
+		})
+	}
+	wg.Wait()
+
+	value, mergeNotes := x.merge(results)
+
+	// Only fail if every datasource failed
+	succeeded := len(x.datasources) - len(mergeNotes)
+	if succeeded == 0 {
+		msg := fmt.Sprintf("unable to query any datasource: %s", strings.Join(mergeNotes, "; "))
+		return Err(method, msg, nil, logger)
+	}
+
+	notes = append(notes, fmt.Sprintf("datasources: %d of %d succeeded", succeeded, len(x.datasources)))
+	notes = append(notes, mergeNotes...)
+	for i, result := range results {
+		for _, note := range result.notes {
+			notes = append(notes, fmt.Sprintf("%s (datasource=%s)", note, x.datasources[i].Name))
+		}
+	}
+
+	logger.Info("Datasources queried",
+		"datasources", len(x.datasources),
+		"succeeded", succeeded,
+	)
+
+	// Results are paged using the default datasource's response budget
+	text, pageNotes, err := x.datasources[0].Client.pageValue(value, format, c)
+	if err != nil {
+		msg := "unable to render query results"
+		return Err(method, msg, err, logger)
+	}
+
+	return withNotes(mcp.NewToolResultText(text), append(notes, pageNotes...)), nil
+}
+
+// merge is a method that merges datasources' results labeling each series with its datasource
+// Scalars are merged as (vector) samples; results of a different type than the first are failures
+// It returns the merged results and a note for each datasource that failed
+func (x *Datasources) merge(results []datasourceResult) (model.Value, []string) {
+	var value model.Value
+	failed := []string{}
+	for i, result := range results {
+		name := x.datasources[i].Name
+		if result.err != nil {
+			failed = append(failed, fmt.Sprintf("failed: datasource=%s: %s", name, result.err))
+			continue
+		}
+
+		// Backends may (incorrectly) return no result without an error
+		if result.value == nil {
+			failed = append(failed, fmt.Sprintf("failed: datasource=%s: no result", name))
+			continue
+		}
+
+		switch v := result.value.(type) {
+		case model.Vector:
+			vector, ok := value.(model.Vector)
+			if value != nil && !ok {
+				failed = append(failed, fmt.Sprintf("failed: datasource=%s: result type %s differs from %s", name, v.Type(), value.Type()))
+				continue
+			}
+			for _, sample := range v {
+				vector = append(vector, &model.Sample{
+					Metric:    inject(sample.Metric, name),
+					Value:     sample.Value,
+					Timestamp: sample.Timestamp,
+					Histogram: sample.Histogram,
+				})
+			}
+			value = vector
+		case *model.Scalar:
+			if v == nil {
+				failed = append(failed, fmt.Sprintf("failed: datasource=%s: no result", name))
+				continue
+			}
+			vector, ok := value.(model.Vector)
+			if value != nil && !ok {
+				failed = append(failed, fmt.Sprintf("failed: datasource=%s: result type %s differs from %s", name, v.Type(), value.Type()))
+				continue
+			}
+			value = append(vector, &model.Sample{
+				Metric:    inject(model.Metric{}, name),
+				Value:     v.Value,
+				Timestamp: v.Timestamp,
+			})
+		case model.Matrix:
+			matrix, ok := value.(model.Matrix)
+			if value != nil && !ok {
+				failed = append(failed, fmt.Sprintf("failed: datasource=%s: result type %s differs from %s", name, v.Type(), value.Type()))
+				continue
+			}
+			for _, stream := range v {
+				matrix = append(matrix, &model.SampleStream{
+					Metric:     inject(stream.Metric, name),
+					Values:     stream.Values,
+					Histograms: stream.Histograms,
+				})
+			}
+			value = matrix
+		default:
+			failed = append(failed, fmt.Sprintf("failed: datasource=%s: result type %s isn't supported", name, result.value.Type()))
+		}
+	}
+
+	// No datasource returned results
+	if value == nil {
+		value = model.Vector{}
+	}
+
+	return value, failed
+}
+
+// inject is a function that returns a copy of a metric labeled by datasource
+// An existing datasource label is preserved as exported_datasource (like Prometheus' honor_labels: false)
+func inject(metric model.Metric, datasource string) model.Metric {
+	m := metric.Clone()
+	if v, ok := m[datasourceLabel]; ok {
+		m[model.ExportedLabelPrefix+datasourceLabel] = v
+	}
+	m[datasourceLabel] = model.LabelValue(datasource)
+	return m
+}
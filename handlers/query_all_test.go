@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/prometheus/client_golang/api"
+	"github.com/prometheus/common/model"
+)
+
+// TestQueryAll tests that QueryAll merges datasources' results and reports failures
+func TestQueryAll(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// Track the number of concurrent queries
+	var inflight, peak atomic.Int32
+
+	// Release delayed handlers so that the servers close promptly
+	done := make(chan struct{})
+
+	// respond is a function that creates a handler that responds to queries with a vector of result after a delay
+	respond := func(result string, delay time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-done:
+				return
+			}
+
+			resp := `{"data":{"resultType":"vector","result":` + result + `},"status":"success"}`
+
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(resp)); err != nil {
+				msg := "error encoding JSON"
+				t.Logf("%s: %+q", msg, err)
+				http.Error(w, msg, http.StatusInternalServerError)
+			}
+		}
+	}
+
+	handlers := map[string]http.HandlerFunc{
+		"east": respond(`[{"metric":{"job":"node"},"value":[1749772800,"1"]}]`, 10*time.Millisecond),
+		// Series that are already labeled by datasource
+		"west": respond(`[{"metric":{"job":"node","datasource":"w1"},"value":[1749772800,"2"]}]`, 10*time.Millisecond),
+		// Exceeds the timeout
+		"north": respond(`[]`, 5*time.Second),
+		"south": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+	}
+
+	datasources := []Datasource{}
+	for _, name := range []string{"east", "west", "north", "south"} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/v1/query", handlers[name])
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		apiClient, err := api.NewClient(api.Config{
+			Address: server.URL,
+		})
+		if err != nil {
+			t.Fatalf("unable to create Prometheus API client: %+q", err)
+		}
+
+		datasources = append(datasources, Datasource{
+			Name:   name,
+			URL:    server.URL,
+			Client: NewClient(apiClient, logger, WithGuardrails(config.Guardrails{})),
+		})
+	}
+
+	defer close(done)
+
+	x := NewDatasources(datasources, logger, WithQueryAll(config.QueryAll{
+		Concurrency: 2,
+		Timeout:     500 * time.Millisecond,
+	}))
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "QueryAll",
+			Arguments: map[string]any{
+				"query": "up",
+			},
+		},
+	}
+	resp, err := x.QueryAll(context.Background(), rqst)
+	if err != nil {
+		t.Fatalf("unable to invoke QueryAll method: %+v", err)
+	}
+
+	t.Logf("Response: %+v", resp)
+
+	vector := model.Vector{}
+	if err := json.Unmarshal([]byte(resp.Content[0].(mcp.TextContent).Text), &vector); err != nil {
+		t.Fatalf("unable to unmarshal vector: %+v", err)
+	}
+	got := []string{}
+	for _, sample := range vector {
+		got = append(got, sample.Metric.String())
+	}
+	sort.Strings(got)
+
+	want := []string{
+		`{datasource="east", job="node"}`,
+		`{datasource="west", exported_datasource="w1", job="node"}`,
+	}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("got: %s; want: %s", got, want)
+	}
+
+	if n := note(resp, "datasources:"); n != "datasources: 2 of 4 succeeded" {
+		t.Errorf("got: %q", n)
+	}
+	failed := []string{}
+	for _, content := range resp.Content[1:] {
+		if text := content.(mcp.TextContent).Text; strings.HasPrefix(text, "failed:") {
+			failed = append(failed, text)
+		}
+	}
+	if len(failed) != 2 || !strings.Contains(failed[0], "datasource=north") || !strings.Contains(failed[1], "datasource=south") {
+		t.Errorf("got: %+q; want: failures for north and south", failed)
+	}
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("got: %d concurrent queries; want: at most 2", p)
+	}
+}
+
+// TestQueryAllFailed tests that QueryAll fails if every datasource fails
+func TestQueryAllFailed(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	x := NewDatasources([]Datasource{
+		{
+			Name:   "east",
+			URL:    server.URL,
+			Client: NewClient(apiClient, logger),
+		},
+	}, logger)
+
+	rqst := mcp.CallToolRequest{
+		Request: mcp.Request{
+			Method: "tools/call",
+		},
+		Params: mcp.CallToolParams{
+			Name: "QueryAll",
+			Arguments: map[string]any{
+				"query": "up",
+			},
+		},
+	}
+	resp, err := x.QueryAll(context.Background(), rqst)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !resp.IsError {
+		t.Errorf("expected error result")
+	}
+}
+
+// TestQueryAllMerge tests that merge reports datasources that return no result as failures
+func TestQueryAllMerge(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	x := NewDatasources([]Datasource{
+		{Name: "east"},
+		{Name: "west"},
+		{Name: "north"},
+	}, logger)
+
+	value, failed := x.merge([]datasourceResult{
+		{value: model.Vector{{Metric: model.Metric{"job": "node"}, Value: 1}}},
+		{value: nil},
+		{value: (*model.Scalar)(nil)},
+	})
+
+	vector, ok := value.(model.Vector)
+	if !ok || len(vector) != 1 || vector[0].Metric[datasourceLabel] != "east" {
+		t.Errorf("got: %v; want: east's sample", value)
+	}
+	if len(failed) != 2 || !strings.Contains(failed[0], "datasource=west") || !strings.Contains(failed[1], "datasource=north") {
+		t.Errorf("got: %+q; want: failures for west and north", failed)
+	}
+}
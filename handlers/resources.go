@@ -0,0 +1,514 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resourceTools maps the URIs (and URI templates) of resources to the tools that return the same information
+// Resources are only published if the tools are published and may only be read by principals that may call the tools
+var resourceTools = map[string][]string{
+	"prometheus://alerts":         {"alerts"},
+	"prometheus://targets":        {"targets"},
+	"prometheus://metrics":        {"metrics"},
+	"prometheus://metrics/{name}": {"metadata", "labels"},
+	"prometheus://rules/{group}":  {"rules"},
+	"prometheus://targets/{job}":  {"targets"},
+	"prometheus://config":         {"status_config"},
+}
+
+// ErrResource is a function that combines logging, metrics and returning resource errors
+func ErrResource(method, msg string, err error, logger *slog.Logger) ([]mcp.ResourceContents, error) {
+	logger.Error(msg, "err", err)
+
+	// Increment Prometheus error metric
+	errorx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	return nil, errors.NewErrToolHandler(msg, err)
+}
+
+// Resources is a method that returns the MCP server resources implemented by Client
+// For every resource defined in this method, there should be a corresponding handler method
+func (x *Client) Resources() []server.ServerResource {
+	return []server.ServerResource{
+		{
+			Resource: mcp.NewResource(
+				"prometheus://alerts",
+				"alerts",
+				mcp.WithResourceDescription("Active (pending and firing) alerts"),
+				mcp.WithMIMEType("application/json"),
+			),
+			Handler: x.AlertsResource,
+		},
+		{
+			Resource: mcp.NewResource(
+				"prometheus://targets",
+				"targets",
+				mcp.WithResourceDescription("Active and dropped targets"),
+				mcp.WithMIMEType("application/json"),
+			),
+			Handler: x.TargetsResource,
+		},
+		{
+			Resource: mcp.NewResource(
+				"prometheus://metrics",
+				"metrics",
+				mcp.WithResourceDescription("List of metric names"),
+				mcp.WithMIMEType("application/json"),
+			),
+			Handler: x.MetricsResource,
+		},
+		{
+			Resource: mcp.NewResource(
+				"prometheus://config",
+				"config",
+				mcp.WithResourceDescription("Prometheus' (currently loaded) configuration"),
+				mcp.WithMIMEType("application/yaml"),
+			),
+			Handler: x.ConfigResource,
+		},
+	}
+}
+
+// ResourceTemplates is a method that returns the MCP server resource templates implemented by Client
+// For every resource template defined in this method, there should be a corresponding handler method
+func (x *Client) ResourceTemplates() []server.ServerResourceTemplate {
+	return []server.ServerResourceTemplate{
+		{
+			Template: mcp.NewResourceTemplate(
+				"prometheus://metrics/{name}",
+				"metric",
+				mcp.WithTemplateDescription("Metadata (type, help, unit) and label names of a metric"),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			Handler: x.MetricResource,
+		},
+		{
+			Template: mcp.NewResourceTemplate(
+				"prometheus://rules/{group}",
+				"rules",
+				mcp.WithTemplateDescription("Alerting and recording rules of a rule group"),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			Handler: x.RulesResource,
+		},
+		{
+			Template: mcp.NewResourceTemplate(
+				"prometheus://targets/{job}",
+				"targets",
+				mcp.WithTemplateDescription("Active and dropped targets of a job"),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			Handler: x.JobTargetsResource,
+		},
+	}
+}
+
+// resourceArgument is a function that returns a resource template's (required) argument
+// URI template variables are matched as lists of values
+func resourceArgument(rqst mcp.ReadResourceRequest, name string) (string, error) {
+	var value string
+	switch v := rqst.Params.Arguments[name].(type) {
+	case string:
+		value = v
+	case []string:
+		if len(v) == 1 {
+			value = v[0]
+		}
+	}
+	if value == "" {
+		return "", fmt.Errorf("resource %q requires a %s", rqst.Params.URI, name)
+	}
+	return value, nil
+}
+
+// jsonResource is a function that returns v as JSON resource contents
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(b),
+		},
+	}, nil
+}
+
+// AlertsResource is a method that reads the active alerts
+func (x *Client) AlertsResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "AlertsResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Alerts method
+	result, err := x.v1api.Alerts(ctx)
+	if err != nil {
+		msg := "unable to retrieve alerts"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	logger.Info("Alerts retrieved",
+		"alerts", len(result.Alerts),
+	)
+
+	contents, err := jsonResource(rqst.Params.URI, result)
+	if err != nil {
+		msg := "unable to marshal alerts"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// TargetsResource is a method that reads the active and dropped targets
+func (x *Client) TargetsResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "TargetsResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Targets method
+	result, err := x.v1api.Targets(ctx)
+	if err != nil {
+		msg := "unable to retrieve targets"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	logger.Info("Targets retrieved",
+		"active", len(result.Active),
+		"dropped", len(result.Dropped),
+	)
+
+	contents, err := jsonResource(rqst.Params.URI, result)
+	if err != nil {
+		msg := "unable to marshal targets"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// MetricsResource is a method that reads the list of metric names
+func (x *Client) MetricsResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "MetricsResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus LabelValues method
+	labelvalues, warnings, err := x.v1api.LabelValues(ctx, "__name__", nil, time.Time{}, time.Time{})
+	if err != nil {
+		msg := "unable to retrieve metrics"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	logger.Info("Metrics retrieved",
+		"metrics", len(labelvalues),
+	)
+
+	// If there are warnings, log them
+	if len(warnings) != 0 {
+		logger.Info("Warnings", "warnings", warnings)
+	}
+
+	contents, err := jsonResource(rqst.Params.URI, labelvalues)
+	if err != nil {
+		msg := "unable to marshal metrics"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// metric is a type that represents a metric's resource
+type metric struct {
+	Name     string        `json:"name"`
+	Metadata []v1.Metadata `json:"metadata"`
+	Labels   []string      `json:"labels"`
+}
+
+// MetricResource is a method that reads a metric's metadata and label names
+func (x *Client) MetricResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "MetricResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	name, err := resourceArgument(rqst, "name")
+	if err != nil {
+		msg := "unable to extract 'name' parameter"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus Metadata method
+	metadata, err := x.v1api.Metadata(ctx, name, "")
+	if err != nil {
+		msg := "unable to retrieve metadata"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus LabelNames method
+	matches := []string{fmt.Sprintf("{__name__=%q}", name)}
+	labelnames, warnings, err := x.v1api.LabelNames(ctx, matches, time.Time{}, time.Time{})
+	if err != nil {
+		msg := "unable to retrieve labels"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	// If there are warnings, log them
+	if len(warnings) != 0 {
+		logger.Info("Warnings", "warnings", warnings)
+	}
+
+	if len(metadata[name]) == 0 && len(labelnames) == 0 {
+		msg := fmt.Sprintf("metric %q not found", name)
+		return ErrResource(method, msg, nil, logger)
+	}
+
+	logger.Info("Metric retrieved",
+		"metric", name,
+		"labels", len(labelnames),
+	)
+
+	contents, err := jsonResource(rqst.Params.URI, metric{
+		Name:     name,
+		Metadata: metadata[name],
+		Labels:   labelnames,
+	})
+	if err != nil {
+		msg := "unable to marshal metric"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// RulesResource is a method that reads a rule group's rules
+// Rule groups are identified by name; groups of the same name (in different files) are all returned
+func (x *Client) RulesResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "RulesResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	group, err := resourceArgument(rqst, "group")
+	if err != nil {
+		msg := "unable to extract 'group' parameter"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus Rules method
+	result, err := x.v1api.Rules(ctx)
+	if err != nil {
+		msg := "unable to retrieve rules"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	groups := []v1.RuleGroup{}
+	for _, g := range result.Groups {
+		if g.Name == group {
+			groups = append(groups, g)
+		}
+	}
+	if len(groups) == 0 {
+		msg := fmt.Sprintf("rule group %q not found", group)
+		return ErrResource(method, msg, nil, logger)
+	}
+
+	logger.Info("Rules retrieved",
+		"group", group,
+		"groups", len(groups),
+	)
+
+	contents, err := jsonResource(rqst.Params.URI, v1.RulesResult{
+		Groups: groups,
+	})
+	if err != nil {
+		msg := "unable to marshal rules"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// JobTargetsResource is a method that reads a job's targets
+// Active targets are identified by their scrape pool and dropped targets by their discovered job label
+func (x *Client) JobTargetsResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "JobTargetsResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	job, err := resourceArgument(rqst, "job")
+	if err != nil {
+		msg := "unable to extract 'job' parameter"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	// Invoke Prometheus Targets method
+	result, err := x.v1api.Targets(ctx)
+	if err != nil {
+		msg := "unable to retrieve targets"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	targets := v1.TargetsResult{
+		Active:  []v1.ActiveTarget{},
+		Dropped: []v1.DroppedTarget{},
+	}
+	for _, t := range result.Active {
+		if t.ScrapePool == job {
+			targets.Active = append(targets.Active, t)
+		}
+	}
+	for _, t := range result.Dropped {
+		if t.DiscoveredLabels["job"] == job {
+			targets.Dropped = append(targets.Dropped, t)
+		}
+	}
+	if len(targets.Active) == 0 && len(targets.Dropped) == 0 {
+		msg := fmt.Sprintf("job %q not found", job)
+		return ErrResource(method, msg, nil, logger)
+	}
+
+	logger.Info("Targets retrieved",
+		"job", job,
+		"active", len(targets.Active),
+		"dropped", len(targets.Dropped),
+	)
+
+	contents, err := jsonResource(rqst.Params.URI, targets)
+	if err != nil {
+		msg := "unable to marshal targets"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return contents, nil
+}
+
+// ConfigResource is a method that reads Prometheus' (currently loaded) configuration
+func (x *Client) ConfigResource(ctx context.Context, rqst mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	method := "ConfigResource"
+	logger := x.logger.With("method", method)
+	logger.Debug("Entered")
+	defer logger.Debug("Exited")
+
+	// Increment Prometheus total metric
+	totalx.With(prometheus.Labels{
+		"tool": method,
+	}).Inc()
+
+	// Invoke Prometheus Status Config method
+	config, err := x.v1api.Config(ctx)
+	if err != nil {
+		msg := "unable to retrieve configuration"
+		return ErrResource(method, msg, err, logger)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      rqst.Params.URI,
+			MIMEType: "application/yaml",
+			Text:     config.YAML,
+		},
+	}, nil
+}
+
+// Resources is a method that returns the MCP server resources of the default datasource
+// Only the resources whose tools are published (see WithTools) are published
+func (x *Datasources) Resources() []server.ServerResource {
+	if len(x.datasources) == 0 || x.datasources[0].Client == nil {
+		return nil
+	}
+
+	resources := []server.ServerResource{}
+	for _, resource := range x.datasources[0].Client.Resources() {
+		uri := resource.Resource.URI
+		if !x.publishesResource(uri) {
+			continue
+		}
+		resource.Handler = x.enforceResource(uri, resource.Handler)
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// ResourceTemplates is a method that returns the MCP server resource templates of the default datasource
+// Only the resource templates whose tools are published (see WithTools) are published
+func (x *Datasources) ResourceTemplates() []server.ServerResourceTemplate {
+	if len(x.datasources) == 0 || x.datasources[0].Client == nil {
+		return nil
+	}
+
+	templates := []server.ServerResourceTemplate{}
+	for _, template := range x.datasources[0].Client.ResourceTemplates() {
+		uri := template.Template.URITemplate.Raw()
+		if !x.publishesResource(uri) {
+			continue
+		}
+		template.Handler = server.ResourceTemplateHandlerFunc(x.enforceResource(uri, server.ResourceHandlerFunc(template.Handler)))
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+// publishesResource is a method that returns whether the resource (or resource template) is published
+func (x *Datasources) publishesResource(uri string) bool {
+	tools, ok := resourceTools[uri]
+	if !ok {
+		return false
+	}
+	for _, tool := range tools {
+		if !x.publishes(tool) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// newResourcesServer is a function that creates a mock Prometheus server for resources
+func newResourcesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	respond := func(data string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if _, err := w.Write([]byte(`{"status":"success","data":` + data + `}`)); err != nil {
+				t.Errorf("unable to write response: %+v", err)
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/label/__name__/values", respond(`["job:up:sum","up"]`))
+	mux.HandleFunc("/api/v1/metadata", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("metric") {
+		case "up":
+			respond(`{"up":[{"type":"gauge","help":"Target is up.","unit":""}]}`)(w, r)
+		default:
+			respond(`{}`)(w, r)
+		}
+	})
+	mux.HandleFunc("/api/v1/labels", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unable to parse form: %+v", err)
+		}
+		switch strings.Join(r.Form["match[]"], ",") {
+		case `{__name__="up"}`:
+			respond(`["__name__","instance","job"]`)(w, r)
+		case `{__name__="job:up:sum"}`:
+			respond(`["__name__","job"]`)(w, r)
+		default:
+			respond(`[]`)(w, r)
+		}
+	})
+	mux.HandleFunc("/api/v1/rules", respond(`{"groups":[
+		{"name":"node","file":"node.yml","interval":60,"rules":[{"type":"alerting","name":"NodeDown","query":"up{job=\"node\"} == 0","duration":300,"labels":{},"annotations":{},"alerts":[],"health":"ok","lastError":"","evaluationTime":0,"lastEvaluation":"2025-06-13T10:00:00Z","state":"inactive"}],"evaluationTime":0,"lastEvaluation":"2025-06-13T10:00:00Z"},
+		{"name":"prometheus","file":"prometheus.yml","interval":60,"rules":[],"evaluationTime":0,"lastEvaluation":"2025-06-13T10:00:00Z"}
+	]}`))
+	mux.HandleFunc("/api/v1/targets", respond(`{
+		"activeTargets":[
+			{"discoveredLabels":{"job":"node"},"labels":{"job":"node","instance":"node:9100"},"scrapePool":"node","scrapeUrl":"http://node:9100/metrics","globalUrl":"http://node:9100/metrics","lastError":"","lastScrape":"2025-06-13T10:00:00Z","lastScrapeDuration":0.01,"health":"up"},
+			{"discoveredLabels":{"job":"prometheus"},"labels":{"job":"prometheus","instance":"localhost:9090"},"scrapePool":"prometheus","scrapeUrl":"http://localhost:9090/metrics","globalUrl":"http://localhost:9090/metrics","lastError":"","lastScrape":"2025-06-13T10:00:00Z","lastScrapeDuration":0.01,"health":"up"}
+		],
+		"droppedTargets":[
+			{"discoveredLabels":{"job":"node","__address__":"node:9101"}}
+		]
+	}`))
+	mux.HandleFunc("/api/v1/alerts", respond(`{"alerts":[]}`))
+	mux.HandleFunc("/api/v1/status/config", respond(`{"yaml":"global:\n  scrape_interval: 15s\n"}`))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestResources tests that resources are read from the default datasource
+func TestResources(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	prometheus := newResourcesServer(t)
+	apiClient, err := api.NewClient(api.Config{
+		Address: prometheus.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	d := NewDatasources([]Datasource{
+		{
+			Name:   config.DefaultDatasource,
+			URL:    prometheus.URL,
+			Client: NewClient(apiClient, logger),
+		},
+	}, logger)
+
+	s := server.NewMCPServer(
+		"MockPrometheusMCP",
+		"0.0.1",
+		server.WithResourceCapabilities(false, false),
+	)
+	s.AddResources(d.Resources()...)
+	s.AddResourceTemplates(d.ResourceTemplates()...)
+
+	httptest := server.NewTestStreamableHTTPServer(s)
+	defer httptest.Close()
+
+	client, err := client.NewStreamableHttpClient(httptest.URL)
+	if err != nil {
+		t.Fatalf("unable to create MCP client: %+v", err)
+	}
+
+	ctx := context.Background()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("unable to start MCP client: %+v", err)
+	}
+	if _, err := client.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
+		t.Fatalf("unable to initialize MCP client: %+v", err)
+	}
+
+	{
+		resp, err := client.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			t.Fatalf("unable to list resources: %+v", err)
+		}
+		got := []string{}
+		for _, resource := range resp.Resources {
+			got = append(got, resource.URI)
+		}
+		slices.Sort(got)
+		want := []string{"prometheus://alerts", "prometheus://config", "prometheus://metrics", "prometheus://targets"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got: %v; want: %v", got, want)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		uri      string
+		ok       bool
+		contains []string
+	}{
+		{name: "metrics", uri: "prometheus://metrics", ok: true, contains: []string{`"job:up:sum"`, `"up"`}},
+		{name: "metric", uri: "prometheus://metrics/up", ok: true, contains: []string{`"name":"up"`, `"type":"gauge"`, `"instance"`}},
+		{name: "recording rule metric", uri: "prometheus://metrics/job%3Aup%3Asum", ok: true, contains: []string{`"name":"job:up:sum"`}},
+		{name: "unknown metric", uri: "prometheus://metrics/down"},
+		{name: "rules", uri: "prometheus://rules/node", ok: true, contains: []string{`"NodeDown"`}},
+		{name: "unknown rules", uri: "prometheus://rules/kubernetes"},
+		{name: "targets", uri: "prometheus://targets/node", ok: true, contains: []string{`"node:9100"`, `"node:9101"`}},
+		{name: "unknown targets", uri: "prometheus://targets/kubernetes"},
+		{name: "config", uri: "prometheus://config", ok: true, contains: []string{"scrape_interval: 15s"}},
+		{name: "alerts", uri: "prometheus://alerts", ok: true, contains: []string{`"alerts":[]`}},
+		{name: "all targets", uri: "prometheus://targets", ok: true, contains: []string{`"node:9100"`, `"localhost:9090"`}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := mcp.ReadResourceRequest{}
+			rqst.Params.URI = test.uri
+			resp, err := client.ReadResource(ctx, rqst)
+			if (err == nil) != test.ok {
+				t.Fatalf("got: %v; want ok: %t", err, test.ok)
+			}
+			if !test.ok {
+				return
+			}
+			if len(resp.Contents) != 1 {
+				t.Fatalf("got: %d contents; want: 1", len(resp.Contents))
+			}
+			contents, ok := resp.Contents[0].(mcp.TextResourceContents)
+			if !ok {
+				t.Fatalf("got: %T; want: mcp.TextResourceContents", resp.Contents[0])
+			}
+			if contents.URI != test.uri {
+				t.Errorf("got: %s; want: %s", contents.URI, test.uri)
+			}
+			for _, s := range test.contains {
+				if !strings.Contains(contents.Text, s) {
+					t.Errorf("got: %s; want: %s", contents.Text, s)
+				}
+			}
+		})
+	}
+
+	// The targets resource excludes other jobs' targets
+	{
+		rqst := mcp.ReadResourceRequest{}
+		rqst.Params.URI = "prometheus://targets/node"
+		resp, err := client.ReadResource(ctx, rqst)
+		if err != nil {
+			t.Fatalf("unable to read resource: %+v", err)
+		}
+		targets := struct {
+			Active  []any `json:"activeTargets"`
+			Dropped []any `json:"droppedTargets"`
+		}{}
+		if err := json.Unmarshal([]byte(resp.Contents[0].(mcp.TextResourceContents).Text), &targets); err != nil {
+			t.Fatalf("unable to unmarshal targets: %+v", err)
+		}
+		if len(targets.Active) != 1 || len(targets.Dropped) != 1 {
+			t.Errorf("got: %d active, %d dropped; want: 1 active, 1 dropped", len(targets.Active), len(targets.Dropped))
+		}
+	}
+}
+
+// TestResourcesTools tests that resources are only published if their tools are published
+func TestResourcesTools(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: p,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	d := NewDatasources([]Datasource{
+		{
+			Name:   config.DefaultDatasource,
+			URL:    p,
+			Client: NewClient(apiClient, logger),
+		},
+	}, logger, WithTools(config.Tools{
+		Deny: config.Names{"status", "labels"},
+	}))
+
+	got := []string{}
+	for _, resource := range d.Resources() {
+		got = append(got, resource.Resource.URI)
+	}
+	for _, template := range d.ResourceTemplates() {
+		got = append(got, template.Template.URITemplate.Raw())
+	}
+	slices.Sort(got)
+
+	want := []string{"prometheus://alerts", "prometheus://metrics", "prometheus://rules/{group}", "prometheus://targets", "prometheus://targets/{job}"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got: %v; want: %v", got, want)
+	}
+}
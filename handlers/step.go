@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// defaultPoints is the target number of points per series when query_range's step is omitted
+	defaultPoints uint64 = 250
+)
+
+// WithQueryRange is a function that configures range queries
+func WithQueryRange(queryRange config.QueryRange) ClientOption {
+	return func(x *Client) {
+		x.queryRange = queryRange
+	}
+}
+
+// autoStep is a method that computes a step for a range query that omits it
+// The step yields at most the target number of points per series and is aligned to the scrape interval of the query's targets
+// It returns the step and a note describing it
+func (x *Client) autoStep(ctx context.Context, query string, r v1.Range, logger *slog.Logger) (time.Duration, string) {
+	points := x.queryRange.Points
+	if points == 0 {
+		points = defaultPoints
+	}
+
+	// Scrape intervals are used to align the step but aren't required
+	var interval time.Duration
+	if expr, err := promql.Parse(query); err == nil {
+		intervals, err := x.scrapeIntervals(ctx)
+		if err != nil {
+			logger.Info("unable to retrieve targets", "err", err)
+		}
+		interval, _ = promql.ScrapeInterval(expr, intervals)
+	}
+
+	d := r.End.Sub(r.Start)
+	step := computeStep(d, points, interval)
+
+	logger.Info("Step computed",
+		"range", d,
+		"points", points,
+		"interval", interval,
+		"step", step,
+	)
+
+	note := fmt.Sprintf("step: %s (computed from the %s range for at most %d points per series",
+		model.Duration(step),
+		model.Duration(d),
+		points,
+	)
+	if interval != 0 {
+		note += fmt.Sprintf(" aligned to the %s scrape interval", model.Duration(interval))
+	}
+	note += ")"
+
+	return step, note
+}
+
+// computeStep is a function that computes the smallest step that yields at most points per series over a range
+// The step is rounded up to a multiple of the scrape interval (or a whole second if the interval is unknown)
+func computeStep(d time.Duration, points uint64, interval time.Duration) time.Duration {
+	align := time.Second
+	if interval > 0 {
+		align = interval
+	}
+
+	if d <= 0 || points < 2 {
+		return align
+	}
+
+	// points = d/step + 1 so step = d/(points-1) rounded up
+	n := time.Duration(points - 1)
+	step := (d + n - 1) / n
+
+	// Round up to a multiple of align
+	if r := step % align; r != 0 {
+		step += align - r
+	}
+
+	return max(step, align)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/testdata"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestQueryRangeStep tests that QueryRange computes an omitted step
+func TestQueryRangeStep(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v1/targets", func(w http.ResponseWriter, r *http.Request) {
+		resp := `{"data":{"activeTargets":[{"discoveredLabels":{"__scrape_interval__":"15s"},"labels":{"job":"prometheus"},"health":"up"}],"droppedTargets":[]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	// Record the step received by Prometheus
+	var step string
+	mux.HandleFunc("/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		step = r.FormValue("step")
+
+		resp := `{"data":{"resultType":"matrix","result":[]},"status":"success"}`
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(resp)); err != nil {
+			msg := "error encoding JSON"
+			t.Logf("%s: %+q", msg, err)
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	c := NewClient(apiClient, logger, WithQueryRange(config.QueryRange{Points: 100}))
+
+	end := testdata.Timestamp
+	tests := []struct {
+		name  string
+		query string
+		start time.Time
+		// Expected step received by Prometheus (seconds) and echoed step
+		want     string
+		wantNote string
+	}{
+		{
+			// 24h/99 = 14m32.7s aligned to 15s
+			name:     "aligned",
+			query:    `up{job="prometheus"}`,
+			start:    end.Add(-24 * time.Hour),
+			want:     "885",
+			wantNote: "step: 14m45s",
+		},
+		{
+			// Scrape interval is unknown so 1h/99 = 36.4s is rounded to 37s
+			name:     "unaligned",
+			query:    `up{job="unknown"}`,
+			start:    end.Add(-time.Hour),
+			want:     "37",
+			wantNote: "step: 37s",
+		},
+		{
+			// Steps are never less than the scrape interval
+			name:     "short",
+			query:    `up{job="prometheus"}`,
+			start:    end.Add(-time.Minute),
+			want:     "15",
+			wantNote: "step: 15s",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := mcp.CallToolRequest{
+				Request: mcp.Request{
+					Method: "tools/call",
+				},
+				Params: mcp.CallToolParams{
+					Name: "QueryRange",
+					Arguments: map[string]any{
+						"query": test.query,
+						"start": test.start.Format(time.RFC3339),
+						"end":   end.Format(time.RFC3339),
+					},
+				},
+			}
+			resp, err := c.QueryRange(context.Background(), rqst)
+			if err != nil {
+				t.Fatalf("unable to invoke QueryRange method: %+v", err)
+			}
+
+			t.Logf("Response: %+v", resp)
+
+			if step != test.want {
+				t.Errorf("step: got: %q; want: %q", step, test.want)
+			}
+
+			if note(resp, test.wantNote) == "" {
+				t.Errorf("expected note: %s", test.wantNote)
+			}
+		})
+	}
+}
+
+// TestComputeStep tests computeStep
+func TestComputeStep(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		points   uint64
+		interval time.Duration
+		want     time.Duration
+	}{
+		{d: time.Hour, points: 61, interval: 0, want: time.Minute},
+		{d: time.Hour, points: 61, interval: 15 * time.Second, want: time.Minute},
+		{d: time.Hour, points: 61, interval: 25 * time.Second, want: 75 * time.Second},
+		{d: time.Hour, points: 11000, interval: 0, want: time.Second},
+		{d: 30 * 24 * time.Hour, points: 11000, interval: 0, want: 236 * time.Second},
+		{d: 0, points: 250, interval: 15 * time.Second, want: 15 * time.Second},
+	}
+	for _, test := range tests {
+		got := computeStep(test.d, test.points, test.interval)
+		if got != test.want {
+			t.Errorf("computeStep(%s, %d, %s): got: %s; want: %s", test.d, test.points, test.interval, got, test.want)
+		}
+
+		// Prometheus' limit
+		if test.d > 0 && uint64(test.d/got)+1 > config.MaxPoints {
+			t.Errorf("computeStep(%s, %d, %s): %s exceeds %d points", test.d, test.points, test.interval, got, config.MaxPoints)
+		}
+	}
+}
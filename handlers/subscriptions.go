@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// subscribable are the URIs of the resources that support subscriptions
+var subscribable = []string{"prometheus://alerts", "prometheus://targets"}
+
+// Subscriptions is a type that represents sessions' subscriptions to resources (of the default datasource)
+// Subscribed resources are polled and subscribers are notified (notifications/resources/updated) when their state changes
+type Subscriptions struct {
+	datasources *Datasources
+	server      *server.MCPServer
+	// sessionIDs is the session ID manager of the (stateful) HTTP server; it identifies the sessions that exist
+	sessionIDs server.SessionIdManager
+	// interval is the interval at which subscribed resources are polled
+	interval time.Duration
+
+	mu sync.Mutex
+	// sessions maps the URIs of resources to the IDs of the sessions that subscribe to them
+	sessions map[string]map[string]struct{}
+	// states maps the URIs of resources to their last polled state
+	states map[string]string
+	logger *slog.Logger
+}
+
+// NewSubscriptions is a function that creates a new Subscriptions
+// sessionIDs must be the session ID manager of the HTTP server (see server.WithSessionIdManager)
+func NewSubscriptions(datasources *Datasources, s *server.MCPServer, sessionIDs server.SessionIdManager, interval time.Duration, logger *slog.Logger) *Subscriptions {
+	return &Subscriptions{
+		datasources: datasources,
+		server:      s,
+		sessionIDs:  sessionIDs,
+		interval:    interval,
+		sessions:    map[string]map[string]struct{}{},
+		states:      map[string]string{},
+		logger:      logger,
+	}
+}
+
+// state is a method that returns the state of a subscribable resource
+// Subscribers are notified when the state changes e.g. an alert starts firing or a target goes down
+func (x *Subscriptions) state(ctx context.Context, uri string) (string, error) {
+	if len(x.datasources.datasources) == 0 || x.datasources.datasources[0].Client == nil {
+		return "", fmt.Errorf("no datasources configured")
+	}
+
+	client := x.datasources.datasources[0].Client
+	switch uri {
+	case "prometheus://alerts":
+		return client.alertsState(ctx)
+	case "prometheus://targets":
+		return client.targetsState(ctx)
+	default:
+		return "", fmt.Errorf("resource %q doesn't support subscriptions", uri)
+	}
+}
+
+// alertsState is a method that returns the state of the active alerts
+// It comprises the alerts (identified by their labels) and their states (pending, firing)
+func (x *Client) alertsState(ctx context.Context) (string, error) {
+	result, err := x.v1api.Alerts(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	states := make([]string, len(result.Alerts))
+	for i, alert := range result.Alerts {
+		states[i] = fmt.Sprintf("%s %s", alert.Labels, alert.State)
+	}
+	slices.Sort(states)
+	return strings.Join(states, "\n"), nil
+}
+
+// targetsState is a method that returns the state of the active targets
+// It comprises the targets (identified by their scrape pool and URL) and their health (up, down, unknown)
+func (x *Client) targetsState(ctx context.Context) (string, error) {
+	result, err := x.v1api.Targets(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	states := make([]string, len(result.Active))
+	for i, target := range result.Active {
+		states[i] = fmt.Sprintf("%s %s %s", target.ScrapePool, target.ScrapeURL, target.Health)
+	}
+	slices.Sort(states)
+	return strings.Join(states, "\n"), nil
+}
+
+// Subscribe is a method that subscribes the session to the resource
+// If the resource isn't yet polled, its current state is the baseline for notifications
+// Only resources that support subscriptions (see state) may be subscribed to
+func (x *Subscriptions) Subscribe(ctx context.Context, sessionID, uri string) error {
+	logger := x.logger.With("method", "Subscribe")
+
+	if !x.datasources.publishesResource(uri) {
+		return fmt.Errorf("resource %q not found", uri)
+	}
+	if !slices.Contains(subscribable, uri) {
+		return fmt.Errorf("resource %q doesn't support subscriptions (expected one of: %s)", uri, strings.Join(subscribable, ", "))
+	}
+	if msg, ok := x.datasources.permitsResource(ctx, uri, uri); !ok {
+		return errors.New(msg)
+	}
+
+	x.mu.Lock()
+	_, polled := x.states[uri]
+	x.mu.Unlock()
+
+	// If Prometheus is unavailable, the first successful poll is the baseline
+	if !polled {
+		state, err := x.state(ctx, uri)
+		if err != nil {
+			logger.Warn("unable to poll resource", "uri", uri, "err", err)
+		} else {
+			x.mu.Lock()
+			if _, ok := x.states[uri]; !ok {
+				x.states[uri] = state
+			}
+			x.mu.Unlock()
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.sessions[uri]; !ok {
+		x.sessions[uri] = map[string]struct{}{}
+	}
+	x.sessions[uri][sessionID] = struct{}{}
+
+	logger.Info("Subscribed", "session", sessionID, "uri", uri)
+	return nil
+}
+
+// Unsubscribe is a method that unsubscribes the session from the resource (or, if uri is empty, from every resource)
+// Resources without subscribers are no longer polled
+func (x *Subscriptions) Unsubscribe(sessionID, uri string) {
+	logger := x.logger.With("method", "Unsubscribe")
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for u, sessions := range x.sessions {
+		if uri != "" && u != uri {
+			continue
+		}
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(x.sessions, u)
+			delete(x.states, u)
+		}
+	}
+
+	logger.Info("Unsubscribed", "session", sessionID, "uri", uri)
+}
+
+// Run is a method that polls the subscribed resources until ctx is done
+func (x *Subscriptions) Run(ctx context.Context) {
+	logger := x.logger.With("method", "Run")
+	logger.Info("Polling subscribed resources", "interval", x.interval)
+
+	ticker := time.NewTicker(x.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			x.poll(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll is a method that polls each subscribed resource and notifies its subscribers if its state changed
+// Sessions that no longer exist are unsubscribed
+func (x *Subscriptions) poll(ctx context.Context) {
+	logger := x.logger.With("method", "poll")
+
+	x.mu.Lock()
+	uris := make([]string, 0, len(x.sessions))
+	for uri := range x.sessions {
+		uris = append(uris, uri)
+	}
+	x.mu.Unlock()
+
+	for _, uri := range uris {
+		ctx, cancel := context.WithTimeout(ctx, x.interval)
+		state, err := x.state(ctx, uri)
+		cancel()
+		if err != nil {
+			logger.Error("unable to poll resource", "uri", uri, "err", err)
+			continue
+		}
+
+		x.mu.Lock()
+		previous, polled := x.states[uri]
+		sessions := make([]string, 0, len(x.sessions[uri]))
+		for sessionID := range x.sessions[uri] {
+			sessions = append(sessions, sessionID)
+		}
+		if len(sessions) != 0 {
+			x.states[uri] = state
+		}
+		x.mu.Unlock()
+
+		if !polled || state == previous {
+			continue
+		}
+
+		logger.Info("Resource updated", "uri", uri, "subscribers", len(sessions))
+		for _, sessionID := range sessions {
+			err := x.server.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+				"uri": uri,
+			})
+			switch {
+			case errors.Is(err, server.ErrSessionNotFound):
+				x.Unsubscribe(sessionID, "")
+			case err != nil:
+				logger.Error("unable to notify session", "session", sessionID, "uri", uri, "err", err)
+			}
+		}
+	}
+}
+
+// exists is a method that returns whether the session exists i.e. its ID was issued by the server and it wasn't deleted
+func (x *Subscriptions) exists(sessionID string) bool {
+	terminated, err := x.sessionIDs.Validate(sessionID)
+	return err == nil && !terminated
+}
+
+// Middleware is a method that handles resources/subscribe and resources/unsubscribe requests
+// These methods aren't implemented by MCPServer; other requests are handled by next
+// Subscriptions are per session (Mcp-Session-Id) and are removed when sessions are deleted
+// Requests of sessions that don't exist are rejected (MCPServer only validates the requests that it handles)
+func (x *Subscriptions) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := x.logger.With("function", "middleware")
+
+		sessionID := r.Header.Get(server.HeaderKeySessionID)
+
+		switch r.Method {
+		case http.MethodDelete:
+			if sessionID != "" {
+				x.Unsubscribe(sessionID, "")
+			}
+			next.ServeHTTP(w, r)
+			return
+		case http.MethodPost:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "unable to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var rqst struct {
+			ID     mcp.RequestId `json:"id"`
+			Method string        `json:"method"`
+			Params struct {
+				URI string `json:"uri"`
+			} `json:"params"`
+		}
+		// Requests that can't be parsed (e.g. batches) are handled by next
+		if err := json.Unmarshal(body, &rqst); err != nil || (rqst.Method != methodSubscribe && rqst.Method != methodUnsubscribe) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var resp any = mcp.NewJSONRPCResultResponse(rqst.ID, mcp.EmptyResult{})
+		switch {
+		case sessionID == "":
+			resp = mcp.NewJSONRPCError(rqst.ID, mcp.INVALID_REQUEST, "subscriptions require a session (Mcp-Session-Id)", nil)
+		case !x.exists(sessionID):
+			logger.Info("unknown session", "session", sessionID, "method", rqst.Method)
+			resp = mcp.NewJSONRPCError(rqst.ID, mcp.INVALID_REQUEST, fmt.Sprintf("session %q not found; initialize a session", sessionID), nil)
+		case rqst.Params.URI == "":
+			resp = mcp.NewJSONRPCError(rqst.ID, mcp.INVALID_PARAMS, "expected 'uri' parameter", nil)
+		case rqst.Method == methodSubscribe:
+			if err := x.Subscribe(r.Context(), sessionID, rqst.Params.URI); err != nil {
+				logger.Info("unable to subscribe", "session", sessionID, "uri", rqst.Params.URI, "err", err)
+				resp = mcp.NewJSONRPCError(rqst.ID, mcp.INVALID_PARAMS, err.Error(), nil)
+			}
+		case rqst.Method == methodUnsubscribe:
+			x.Unsubscribe(sessionID, rqst.Params.URI)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logger.Error("unable to write response", "err", err)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestSubscriptions tests that subscribers are notified when alerts start firing or targets go down
+func TestSubscriptions(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// The mock Prometheus server's alert state and target health may be changed
+	var mu sync.Mutex
+	alertState := "pending"
+	health := "up"
+	set := func(state, h string) {
+		mu.Lock()
+		defer mu.Unlock()
+		alertState = state
+		health = h
+	}
+
+	mux := http.NewServeMux()
+	promServer := httptest.NewServer(mux)
+	defer promServer.Close()
+
+	mux.HandleFunc("/api/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"alerts":[{"labels":{"alertname":"NodeDown","job":"node"},"annotations":{},"state":"` + alertState + `","activeAt":"2025-06-13T10:00:00Z","value":"0"}]}}`))
+	})
+	mux.HandleFunc("/api/v1/targets", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"activeTargets":[{"discoveredLabels":{},"labels":{"job":"node"},"scrapePool":"node","scrapeUrl":"http://node:9100/metrics","globalUrl":"http://node:9100/metrics","lastError":"","lastScrape":"2025-06-13T10:00:00Z","lastScrapeDuration":0.01,"health":"` + health + `"}],"droppedTargets":[]}}`))
+	})
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: promServer.URL,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+	d := NewDatasources([]Datasource{
+		{
+			Name:   config.DefaultDatasource,
+			URL:    promServer.URL,
+			Client: NewClient(apiClient, logger),
+		},
+	}, logger)
+
+	s := server.NewMCPServer(
+		"MockPrometheusMCP",
+		"0.0.1",
+		server.WithResourceCapabilities(true, false),
+	)
+	s.AddResources(d.Resources()...)
+
+	sessionIDs := &server.InsecureStatefulSessionIdManager{}
+	subscriptions := NewSubscriptions(d, s, sessionIDs, time.Minute, logger)
+	streamServer := server.NewStreamableHTTPServer(s, server.WithSessionIdManager(sessionIDs))
+	mcpServer := httptest.NewServer(subscriptions.Middleware(streamServer))
+	defer mcpServer.Close()
+
+	c, err := client.NewStreamableHttpClient(mcpServer.URL, transport.WithContinuousListening())
+	if err != nil {
+		t.Fatalf("unable to create MCP client: %+v", err)
+	}
+	defer c.Close()
+
+	updated := make(chan string, 10)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationResourceUpdated {
+			uri, _ := notification.Params.AdditionalFields["uri"].(string)
+			updated <- uri
+		}
+	})
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("unable to start MCP client: %+v", err)
+	}
+	resp, err := c.Initialize(ctx, mcp.InitializeRequest{})
+	if err != nil {
+		t.Fatalf("unable to initialize MCP client: %+v", err)
+	}
+	if resp.Capabilities.Resources == nil || !resp.Capabilities.Resources.Subscribe {
+		t.Fatalf("expected resources subscribe capability")
+	}
+
+	subscribe := func(uri string) error {
+		rqst := mcp.SubscribeRequest{}
+		rqst.Params.URI = uri
+		return c.Subscribe(ctx, rqst)
+	}
+	// expect is a function that polls the subscribed resources and expects notifications of the updated resources (if any)
+	expect := func(want ...string) {
+		t.Helper()
+		subscriptions.poll(ctx)
+		for _, uri := range want {
+			select {
+			case got := <-updated:
+				if got != uri {
+					t.Errorf("got: %s; want: %s", got, uri)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("expected notification of %s", uri)
+			}
+		}
+		select {
+		case got := <-updated:
+			t.Errorf("got: %s; want: no notification", got)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	for _, uri := range []string{"prometheus://alerts", "prometheus://targets"} {
+		if err := subscribe(uri); err != nil {
+			t.Fatalf("unable to subscribe to %s: %+v", uri, err)
+		}
+	}
+	if err := subscribe("prometheus://config"); err == nil {
+		t.Errorf("expected error")
+	}
+
+	// Nothing changed
+	expect()
+
+	// The alert starts firing
+	set("firing", "up")
+	expect("prometheus://alerts")
+
+	// The target goes down
+	set("firing", "down")
+	expect("prometheus://targets")
+
+	// Unsubscribed resources aren't polled
+	rqst := mcp.UnsubscribeRequest{}
+	rqst.Params.URI = "prometheus://alerts"
+	if err := c.Unsubscribe(ctx, rqst); err != nil {
+		t.Fatalf("unable to unsubscribe: %+v", err)
+	}
+	set("pending", "up")
+	expect("prometheus://targets")
+}
+
+// TestSubscriptionsSession tests that subscriptions require a session that exists
+func TestSubscriptionsSession(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	s := server.NewMCPServer(
+		"MockPrometheusMCP",
+		"0.0.1",
+	)
+	sessionIDs := &server.InsecureStatefulSessionIdManager{}
+	subscriptions := NewSubscriptions(NewDatasources(nil, logger), s, sessionIDs, time.Minute, logger)
+	handler := subscriptions.Middleware(server.NewStreamableHTTPServer(s, server.WithSessionIdManager(sessionIDs)))
+
+	// A session ID that the server didn't issue (well-formed) and a session ID that was deleted
+	forged := "mcp-session-5f1d3c2e-8a4b-4c6d-9e0f-1a2b3c4d5e6f"
+	deleted := sessionIDs.Generate()
+	if _, err := sessionIDs.Terminate(deleted); err != nil {
+		t.Fatalf("unable to terminate session: %+v", err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		want      string
+	}{
+		{name: "no session", sessionID: "", want: "Mcp-Session-Id"},
+		{name: "forged session", sessionID: forged, want: "not found"},
+		{name: "deleted session", sessionID: deleted, want: "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rqst := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"prometheus://alerts"}}`))
+			rqst.Header.Set("Content-Type", "application/json")
+			if test.sessionID != "" {
+				rqst.Header.Set(server.HeaderKeySessionID, test.sessionID)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, rqst)
+
+			if body := resp.Body.String(); !strings.Contains(body, test.want) || !strings.Contains(body, fmt.Sprint(mcp.INVALID_REQUEST)) {
+				t.Errorf("got: %s; want: session error", body)
+			}
+		})
+	}
+
+	subscriptions.mu.Lock()
+	defer subscriptions.mu.Unlock()
+	if len(subscriptions.sessions) != 0 {
+		t.Errorf("got: %v; want: no subscriptions", subscriptions.sessions)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DazWilkin/prometheus-mcp-server/transport"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// tenant is a method that wraps a datasource's tool handler so that calls may specify the tenant using the "tenant" argument
+// The tenant must be one of the datasource's allowed tenants; if omitted, requests use the datasource's default tenant
+// The tenant is added to the context so that every request of the call (including guardrails' probes) identifies it
+func (x *Datasources) tenant(handler server.ToolHandlerFunc, d Datasource) server.ToolHandlerFunc {
+	return func(ctx context.Context, rqst mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tenant, _ := rqst.GetArguments()["tenant"].(string)
+		if tenant == "" {
+			return handler(ctx, rqst)
+		}
+
+		method := rqst.Params.Name
+		logger := x.logger.With("method", method)
+
+		if !d.Tenant.Allows(tenant) {
+			msg := fmt.Sprintf("tenant %q isn't allowed for datasource %q", tenant, d.Name)
+			if len(d.Tenant.Allowed) != 0 {
+				msg = fmt.Sprintf("%s (expected one of: %s)", msg, strings.Join(d.Tenant.Allowed, ", "))
+			}
+			return Err(method, msg, nil, logger)
+		}
+
+		logger.Debug("Tenant", "datasource", d.Name, "tenant", tenant)
+		return handler(transport.WithTenant(ctx, tenant), rqst)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Groups maps the names of groups of tools to the names of their tools
+// Tools may be allowed|denied individually or by group (see config.Tools); group names must not be tool names
+var Groups = map[string][]string{
+	"queries": {
+		"query",
+		"query_range",
+		"query_all",
+		"exemplars",
+		"format_query",
+		"lint_query",
+		"validate_query",
+	},
+	"discovery": {
+		"datasources",
+		"metrics",
+		"metadata",
+		"labels",
+		"label_values",
+		"series",
+		"targets",
+		"targets_metadata",
+		"rules",
+		"alerts",
+		"alertmanagers",
+	},
+	"status": {
+		"status_buildinfo",
+		"status_config",
+		"status_flags",
+		"status_runtimeinfo",
+		"status_tsdb",
+		"status_walreplay",
+	},
+	"admin": {
+		"clean_tombstones",
+		"delete_series",
+		"snapshot",
+	},
+	"management": {
+		"healthy",
+		"ping",
+		"reload",
+		"quit",
+	},
+}
+
+// writes are the tools that change Prometheus' state or data; these aren't published in read-only mode
+var writes = []string{"clean_tombstones", "delete_series", "snapshot", "reload", "quit"}
+
+// WithTools is a function that configures the tools that are published
+func WithTools(c config.Tools) DatasourcesOption {
+	return func(x *Datasources) {
+		x.tools = c
+	}
+}
+
+// matches is a function that returns whether the tool is one of names or a member of one of the named groups
+func matches(tool string, names []string) bool {
+	return slices.ContainsFunc(names, func(name string) bool {
+		return name == tool || slices.Contains(Groups[name], tool)
+	})
+}
+
+// filter is a method that returns the tools that are published
+// Tools that are denied (or, in read-only mode, that change state or data) are removed
+// If any tools are allowed, only these are published
+func (x *Datasources) filter(tools []server.ServerTool) []server.ServerTool {
+	logger := x.logger.With("method", "filter")
+
+	// Names that match neither a group nor a tool (every tool is a member of a group) are likely typos
+	for _, name := range slices.Concat(x.tools.Allow, x.tools.Deny) {
+		if _, ok := Groups[name]; ok {
+			continue
+		}
+		if !slices.ContainsFunc(slices.Collect(maps.Values(Groups)), func(tools []string) bool {
+			return slices.Contains(tools, name)
+		}) {
+			logger.Warn("Unknown tool or group of tools", "name", name)
+		}
+	}
+
+	return slices.DeleteFunc(tools, func(tool server.ServerTool) bool {
+		return !x.publishes(tool.Tool.Name)
+	})
+}
+
+// publishes is a method that returns whether the tool is published
+func (x *Datasources) publishes(tool string) bool {
+	switch {
+	case x.tools.ReadOnly && slices.Contains(writes, tool):
+		return false
+	case len(x.tools.Allow) != 0 && !matches(tool, x.tools.Allow):
+		return false
+	case matches(tool, x.tools.Deny):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+	"github.com/DazWilkin/prometheus-mcp-server/testdata"
+
+	"github.com/prometheus/client_golang/api"
+)
+
+// TestTools tests that only the configured tools are published
+// The tools are those of the ClientToolsTests and MetaToolsTests tables
+func TestTools(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	apiClient, err := api.NewClient(api.Config{
+		Address: p,
+	})
+	if err != nil {
+		t.Fatalf("unable to create Prometheus API client: %+q", err)
+	}
+
+	// list is a function that returns the names of the tools that are published
+	list := func(c config.Tools) []string {
+		d := Datasource{
+			Name:   config.DefaultDatasource,
+			URL:    p,
+			Client: NewClient(apiClient, logger, WithAdmin(true)),
+			Meta:   NewMeta(p, logger, WithManagementWrites(true)),
+		}
+		names := []string{}
+		for _, tool := range NewDatasources([]Datasource{d}, logger, WithTools(c)).Tools() {
+			names = append(names, tool.Tool.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	// tables is a function that returns the (sorted) names of the tools of the tables and any others
+	tables := func(others ...string) []string {
+		names := slices.Concat(
+			slices.Collect(maps.Keys(testdata.ClientToolsTests)),
+			slices.Collect(maps.Keys(testdata.MetaToolsTests)),
+			others,
+		)
+		slices.Sort(names)
+		return names
+	}
+
+	// The tools that aren't tested by the tables
+	others := []string{"datasources", "query_all", "clean_tombstones", "delete_series", "snapshot", "reload", "quit"}
+
+	// Every tool is a member of a group and no group has the name of a tool
+	for _, name := range list(config.Tools{}) {
+		if !slices.ContainsFunc(slices.Collect(maps.Values(Groups)), func(tools []string) bool {
+			return slices.Contains(tools, name)
+		}) {
+			t.Errorf("%s: expected tool to be a member of a group", name)
+		}
+		if _, ok := Groups[name]; ok {
+			t.Errorf("%s: expected group not to have the name of a tool", name)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		tools config.Tools
+		want  []string
+	}{
+		{
+			name:  "default",
+			tools: config.Tools{},
+			want:  tables(others...),
+		},
+		{
+			name: "allow",
+			tools: config.Tools{
+				Allow: config.Names{"query", "metrics"},
+			},
+			want: []string{"metrics", "query"},
+		},
+		{
+			name: "allow group",
+			tools: config.Tools{
+				Allow: config.Names{"queries", "metrics"},
+			},
+			want: []string{"exemplars", "format_query", "lint_query", "metrics", "query", "query_all", "query_range", "validate_query"},
+		},
+		{
+			name: "allow and deny",
+			tools: config.Tools{
+				Allow: config.Names{"queries", "metrics"},
+				Deny:  config.Names{"query_all", "exemplars", "format_query", "lint_query", "validate_query"},
+			},
+			want: []string{"metrics", "query", "query_range"},
+		},
+		{
+			name: "deny",
+			tools: config.Tools{
+				Deny: config.Names{"discovery", "status", "admin", "management"},
+			},
+			want: []string{"exemplars", "format_query", "lint_query", "query", "query_all", "query_range", "validate_query"},
+		},
+		{
+			name: "read-only",
+			tools: config.Tools{
+				ReadOnly: true,
+			},
+			want: tables("datasources", "query_all"),
+		},
+		{
+			name: "read-only and allow",
+			tools: config.Tools{
+				Allow:    config.Names{"management"},
+				ReadOnly: true,
+			},
+			want: []string{"healthy", "ping"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := list(test.tools)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got: %v; want: %v", got, test.want)
+			}
+		})
+	}
+}
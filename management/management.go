@@ -1,9 +1,13 @@
 package management
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -11,34 +15,72 @@ import (
 type Client struct {
 	client     *http.Client
 	prometheus string
-	logger     *slog.Logger
+	// readyPath is the path of the readiness check relative to the URL's host; empty is {prometheus}/-/ready
+	readyPath string
+	logger    *slog.Logger
+}
+
+// ClientOption is a type that represents an optional configuration of Client
+type ClientOption func(*Client)
+
+// WithRoundTripper is a function that configures the http.RoundTripper used by Client e.g. to authenticate requests
+func WithRoundTripper(rt http.RoundTripper) ClientOption {
+	return func(x *Client) {
+		x.client.Transport = rt
+	}
+}
+
+// WithReadyPath is a function that configures the path of the readiness check e.g. /ready for Mimir and Cortex
+// The path is relative to the URL's host since these backends serve the Prometheus API under a prefix e.g. /prometheus
+func WithReadyPath(path string) ClientOption {
+	return func(x *Client) {
+		x.readyPath = path
+	}
 }
 
 // NewClient is a function that creates a new ManagementAPI
-func NewClient(prometheus string, logger *slog.Logger) *Client {
+func NewClient(prometheus string, logger *slog.Logger, opts ...ClientOption) *Client {
 	// Need an HTTP client
 	client := &http.Client{
 		Timeout: 5 * time.Second,
 	}
 
-	return &Client{
+	x := &Client{
 		client:     client,
 		prometheus: prometheus,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
 }
 
 // Do is a function that invokes Prometheus Management API methods
-func (x *Client) Do(method string) int {
+// It returns the response's status code and (trimmed) body
+// The Management API returns explanatory bodies e.g. "Lifecycle API is not enabled."
+func (x *Client) Do(ctx context.Context, httpMethod, method string) (int, string, error) {
+	return x.do(ctx, httpMethod, method, fmt.Sprintf("%s/-/%s", x.prometheus, method))
+}
+
+// do is a method that invokes the URL
+func (x *Client) do(ctx context.Context, httpMethod, method, url string) (int, string, error) {
 	logger := x.logger.With("method", method)
 	logger.Debug("Entered")
 	defer logger.Debug("Exited")
 
-	url := fmt.Sprintf("%s/-/%s", x.prometheus, method)
+	rqst, err := http.NewRequestWithContext(ctx, httpMethod, url, nil)
+	if err != nil {
+		msg := "unable to create request"
+		logger.Error(msg, "err", err)
+		return http.StatusInternalServerError, "", err
+	}
 
-	resp, err := x.client.Get(url)
+	resp, err := x.client.Do(rqst)
 	if err != nil {
-		return http.StatusInternalServerError
+		msg := "unable to invoke method"
+		logger.Error(msg, "url", url, "err", err)
+		return http.StatusInternalServerError, "", err
 	}
 	defer func() {
 		x.logger.Debug("Closing response body", "url", url)
@@ -48,17 +90,49 @@ func (x *Client) Do(method string) int {
 		}
 	}()
 
-	return resp.StatusCode
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		msg := "unable to read response body"
+		logger.Error(msg, "err", err)
+		return resp.StatusCode, "", err
+	}
+
+	return resp.StatusCode, strings.TrimSpace(string(b)), nil
 }
 
 // Healthy is a method that represents the Prometheus Management API Health check
-func (x *Client) Healthy() int {
+func (x *Client) Healthy(ctx context.Context) (int, string, error) {
 	method := "healthy"
-	return x.Do(method)
+	return x.Do(ctx, http.MethodGet, method)
 }
 
 // Ready is a method that represents the Prometheus Management API Readiness check
-func (x *Client) Ready() int {
+// If configured (see WithReadyPath), the backend-specific readiness path is used
+func (x *Client) Ready(ctx context.Context) (int, string, error) {
 	method := "ready"
-	return x.Do(method)
+	if x.readyPath == "" {
+		return x.Do(ctx, http.MethodGet, method)
+	}
+
+	base, err := url.Parse(x.prometheus)
+	if err != nil {
+		msg := "unable to parse URL"
+		x.logger.Error(msg, "url", x.prometheus, "err", err)
+		return http.StatusInternalServerError, "", err
+	}
+	return x.do(ctx, http.MethodGet, method, base.ResolveReference(&url.URL{Path: x.readyPath}).String())
+}
+
+// Reload is a method that represents the Prometheus Management API Reload
+// Requires that Prometheus be started with --web.enable-lifecycle
+func (x *Client) Reload(ctx context.Context) (int, string, error) {
+	method := "reload"
+	return x.Do(ctx, http.MethodPost, method)
+}
+
+// Quit is a method that represents the Prometheus Management API Quit
+// Requires that Prometheus be started with --web.enable-lifecycle
+func (x *Client) Quit(ctx context.Context) (int, string, error) {
+	method := "quit"
+	return x.Do(ctx, http.MethodPost, method)
 }
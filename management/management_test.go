@@ -1,6 +1,7 @@
 package management
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,21 +12,36 @@ import (
 
 var (
 	tests = []struct {
-		name string
+		name       string
+		httpMethod string
 		// A clever way to reference a type's (Client's) methods
-		// Since both the handlers a func() int, we can generalize
-		handler func(*Client) int
+		// Since all the handlers are func(context.Context) (int, string, error), we can generalize
+		handler func(*Client, context.Context) (int, string, error)
 		want    int
 	}{
 		{
-			name:    "healthy",
-			handler: (*Client).Healthy,
-			want:    http.StatusOK,
+			name:       "healthy",
+			httpMethod: http.MethodGet,
+			handler:    (*Client).Healthy,
+			want:       http.StatusOK,
 		},
 		{
-			name:    "ready",
-			handler: (*Client).Ready,
-			want:    http.StatusOK,
+			name:       "ready",
+			httpMethod: http.MethodGet,
+			handler:    (*Client).Ready,
+			want:       http.StatusOK,
+		},
+		{
+			name:       "reload",
+			httpMethod: http.MethodPost,
+			handler:    (*Client).Reload,
+			want:       http.StatusOK,
+		},
+		{
+			name:       "quit",
+			httpMethod: http.MethodPost,
+			handler:    (*Client).Quit,
+			want:       http.StatusOK,
 		},
 	}
 )
@@ -36,6 +52,12 @@ func okHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// lifecycleHandler mimics Prometheus when it is not started with --web.enable-lifecycle
+func lifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "Lifecycle API is not enabled.", http.StatusForbidden)
+}
+
 func TestManagement(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -45,13 +67,16 @@ func TestManagement(t *testing.T) {
 			ts := httptest.NewServer(mux)
 			defer ts.Close()
 
-			pattern := fmt.Sprintf("GET /-/%s", test.name)
+			pattern := fmt.Sprintf("%s /-/%s", test.httpMethod, test.name)
 			mux.HandleFunc(pattern, okHandler)
 			url := ts.URL
 
 			client := NewClient(url, logger)
 			// The corresponding way pass the receiver (*Client)
-			got := test.handler(client)
+			got, body, err := test.handler(client, context.Background())
+			if err != nil {
+				t.Fatalf("expected success: %+v", err)
+			}
 			want := test.want
 
 			if got != want {
@@ -60,6 +85,99 @@ func TestManagement(t *testing.T) {
 					http.StatusText(want), want,
 				)
 			}
+
+			if body != "OK" {
+				t.Errorf("got: %q; want: %q", body, "OK")
+			}
 		})
 	}
 }
+
+func TestManagementLifecycleDisabled(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("POST /-/reload", lifecycleHandler)
+
+	client := NewClient(ts.URL, logger)
+	got, body, err := client.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+
+	if got != http.StatusForbidden {
+		t.Errorf("got: %d; want: %d", got, http.StatusForbidden)
+	}
+
+	want := "Lifecycle API is not enabled."
+	if body != want {
+		t.Errorf("got: %q; want: %q", body, want)
+	}
+}
+
+// roundTripperFunc is a type that adapts a function into an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip is a method that implements http.RoundTripper
+func (f roundTripperFunc) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	return f(rqst)
+}
+
+// TestManagementRoundTripper tests that requests use the configured RoundTripper
+func TestManagementRoundTripper(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	mux.HandleFunc("GET /-/healthy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		okHandler(w, r)
+	})
+
+	rt := roundTripperFunc(func(rqst *http.Request) (*http.Response, error) {
+		rqst = rqst.Clone(rqst.Context())
+		rqst.Header.Set("Authorization", "Bearer token")
+		return http.DefaultTransport.RoundTrip(rqst)
+	})
+
+	client := NewClient(ts.URL, logger, WithRoundTripper(rt))
+	got, _, err := client.Healthy(context.Background())
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if got != http.StatusOK {
+		t.Errorf("got: %d; want: %d", got, http.StatusOK)
+	}
+}
+
+// TestManagementReadyPath tests that the readiness check uses the backend-specific path relative to the URL's host
+func TestManagementReadyPath(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Mimic Mimir which serves the Prometheus API under /prometheus and the readiness check at /ready
+	mux.HandleFunc("GET /ready", okHandler)
+
+	client := NewClient(ts.URL+"/prometheus", logger, WithReadyPath("/ready"))
+	got, body, err := client.Ready(context.Background())
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	if got != http.StatusOK {
+		t.Errorf("got: %d; want: %d", got, http.StatusOK)
+	}
+	if body != "OK" {
+		t.Errorf("got: %q; want: %q", body, "OK")
+	}
+}
@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/promql"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/prometheus/model/labels"
+	"gopkg.in/yaml.v3"
+)
+
+// Any matches any principal (including unauthenticated principals) or tool
+const Any string = "*"
+
+// Queries are the tools whose "query" argument is a PromQL expression to which a rule's label matchers are added
+var Queries = []string{"query", "query_range", "query_all", "exemplars"}
+
+// Matches are the tools whose "match[]" argument are series selectors to which a rule's label matchers are added
+// If "match[]" is omitted, it is the rule's label matchers
+var Matches = []string{"series", "labels", "label_values", "delete_series"}
+
+// Unrestricted are the tools that don't access series and so needn't be restricted by a rule's label matchers
+var Unrestricted = []string{
+	"datasources",
+	"format_query",
+	"validate_query",
+	"healthy",
+	"ping",
+	"status_buildinfo",
+	"status_flags",
+	"status_runtimeinfo",
+	"status_walreplay",
+}
+
+// Restricts is a function that returns whether a rule's label matchers restrict the tool's access to series
+func Restricts(tool string) bool {
+	return slices.Contains(Queries, tool) || slices.Contains(Matches, tool)
+}
+
+// Rule is a type that represents the tools and series that principals may access
+type Rule struct {
+	Name string `yaml:"name"`
+	// Principals and Groups identify the principals to which the rule applies
+	Principals []string `yaml:"principals,omitempty"`
+	Groups     []string `yaml:"groups,omitempty"`
+	// Tools are the names of the tools that the principals may call
+	Tools []string `yaml:"tools"`
+	// Matchers are label matchers e.g. namespace="team-a" that are added to every PromQL selector
+	Matchers []string `yaml:"matchers,omitempty"`
+
+	matchers []*labels.Matcher
+}
+
+// Applies is a method that returns whether the rule applies to the principal
+// A nil principal represents an unauthenticated principal
+func (r *Rule) Applies(principal *auth.Principal) bool {
+	if slices.Contains(r.Principals, Any) {
+		return true
+	}
+	if principal == nil {
+		return false
+	}
+	if slices.Contains(r.Principals, principal.Name) {
+		return true
+	}
+	return slices.ContainsFunc(principal.Groups, func(group string) bool {
+		return slices.Contains(r.Groups, group)
+	})
+}
+
+// Allows is a method that returns whether the rule allows the tool
+func (r *Rule) Allows(tool string) bool {
+	return slices.Contains(r.Tools, Any) || slices.Contains(r.Tools, tool)
+}
+
+// LabelMatchers is a method that returns the (parsed) label matchers
+func (r *Rule) LabelMatchers() []*labels.Matcher {
+	return r.matchers
+}
+
+// Policy is a type that represents an ordered list of rules
+// The first rule that applies to a principal determines its access; principals to which no rule applies have no access
+type Policy struct {
+	Rules []*Rule `yaml:"policies"`
+}
+
+// Load is a function that loads the policy from a YAML file e.g.:
+//
+//	policies:
+//	- name: team-a
+//	  groups:
+//	  - team-a
+//	  tools:
+//	  - query
+//	  - query_range
+//	  matchers:
+//	  - namespace="team-a"
+func Load(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		msg := fmt.Sprintf("unable to read policy file %q", path)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+
+	p := &Policy{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		msg := fmt.Sprintf("unable to parse policy file %q", path)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+
+	if err := p.init(); err != nil {
+		msg := fmt.Sprintf("policy file %q is invalid", path)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+
+	return p, nil
+}
+
+// init is a method that validates the rules and parses their label matchers
+func (p *Policy) init() error {
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("#%d", i)
+		}
+		if len(r.Principals) == 0 && len(r.Groups) == 0 {
+			return fmt.Errorf("policy %q requires principals or groups", r.Name)
+		}
+		if len(r.Tools) == 0 {
+			return fmt.Errorf("policy %q requires tools (%q allows every tool)", r.Name, Any)
+		}
+
+		matchers, err := promql.ParseMatchers(r.Matchers)
+		if err != nil {
+			return fmt.Errorf("policy %q: %w", r.Name, err)
+		}
+		r.matchers = matchers
+
+		// Label matchers can't restrict the other tools (e.g. metrics, targets) so these can't be allowed
+		if len(r.matchers) != 0 {
+			for _, tool := range r.Tools {
+				if !Restricts(tool) && !slices.Contains(Unrestricted, tool) {
+					return fmt.Errorf("policy %q: matchers can't restrict %q; allow it in a policy without matchers", r.Name, tool)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Rule is a method that returns the first rule that applies to the principal of ctx (if any)
+func (p *Policy) Rule(ctx context.Context) (*Rule, bool) {
+	principal, _ := auth.PrincipalFrom(ctx)
+	for _, r := range p.Rules {
+		if r.Applies(principal) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// Filter is a method that implements server.ToolFilterFunc
+// It lists the tools that the principal of ctx may call
+func (p *Policy) Filter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	r, ok := p.Rule(ctx)
+	if !ok {
+		return []mcp.Tool{}
+	}
+	return slices.DeleteFunc(slices.Clone(tools), func(tool mcp.Tool) bool {
+		return !r.Allows(tool.Name)
+	})
+}
@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/auth"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// load is a function that loads a policy from YAML
+func load(t *testing.T, s string) (*Policy, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	return Load(path)
+}
+
+// TestPolicy tests that the first rule that applies to a principal determines its access
+func TestPolicy(t *testing.T) {
+	p, err := load(t, `policies:
+- name: admins
+  principals:
+  - alice
+  tools:
+  - "*"
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - query
+  - query_range
+  matchers:
+  - namespace="team-a"
+- name: anyone
+  principals:
+  - "*"
+  tools:
+  - validate_query
+`)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		policy    string
+		allowed   []string
+		denied    []string
+	}{
+		{name: "principal", principal: &auth.Principal{Name: "alice", Groups: []string{"team-a"}}, policy: "admins", allowed: []string{"query", "delete_series"}},
+		{name: "group", principal: &auth.Principal{Name: "bob", Groups: []string{"team-a"}}, policy: "team-a", allowed: []string{"query", "query_range"}, denied: []string{"series", "validate_query"}},
+		{name: "any", principal: &auth.Principal{Name: "carol", Groups: []string{"team-b"}}, policy: "anyone", allowed: []string{"validate_query"}, denied: []string{"query"}},
+		{name: "unauthenticated", policy: "anyone", allowed: []string{"validate_query"}, denied: []string{"query"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.principal != nil {
+				ctx = auth.WithPrincipal(ctx, test.principal)
+			}
+
+			r, ok := p.Rule(ctx)
+			if !ok {
+				t.Fatalf("expected policy")
+			}
+			if r.Name != test.policy {
+				t.Errorf("got: %q; want: %q", r.Name, test.policy)
+			}
+			for _, tool := range test.allowed {
+				if !r.Allows(tool) {
+					t.Errorf("expected %q to be allowed", tool)
+				}
+			}
+			for _, tool := range test.denied {
+				if r.Allows(tool) {
+					t.Errorf("expected %q to be denied", tool)
+				}
+			}
+		})
+	}
+
+	{
+		r, _ := p.Rule(auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob", Groups: []string{"team-a"}}))
+		if got := len(r.LabelMatchers()); got != 1 {
+			t.Errorf("got: %d matchers; want: 1", got)
+		}
+	}
+}
+
+// TestPolicyNoRule tests that principals to which no rule applies have no access
+func TestPolicyNoRule(t *testing.T) {
+	p, err := load(t, `policies:
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - query
+`)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "mallory"})
+	if _, ok := p.Rule(ctx); ok {
+		t.Errorf("expected no policy")
+	}
+	if got := p.Filter(ctx, []mcp.Tool{{Name: "query"}}); len(got) != 0 {
+		t.Errorf("got: %+v; want: no tools", got)
+	}
+}
+
+// TestPolicyFilter tests that principals only list the tools that they may call
+func TestPolicyFilter(t *testing.T) {
+	p, err := load(t, `policies:
+- name: team-a
+  groups:
+  - team-a
+  tools:
+  - query
+  - series
+`)
+	if err != nil {
+		t.Fatalf("unable to load policy: %+v", err)
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob", Groups: []string{"team-a"}})
+	tools := []mcp.Tool{{Name: "query"}, {Name: "series"}, {Name: "delete_series"}}
+
+	got := []string{}
+	for _, tool := range p.Filter(ctx, tools) {
+		got = append(got, tool.Name)
+	}
+	if want := []string{"query", "series"}; !slices.Equal(got, want) {
+		t.Errorf("got: %+q; want: %+q", got, want)
+	}
+	if len(tools) != 3 {
+		t.Errorf("expected tools to be unchanged")
+	}
+}
+
+// TestLoadInvalid tests that invalid policies are rejected
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{name: "no principals", yaml: "policies:\n- name: x\n  tools: [query]\n"},
+		{name: "no tools", yaml: "policies:\n- name: x\n  principals: [alice]\n"},
+		{name: "invalid matcher", yaml: "policies:\n- name: x\n  principals: [alice]\n  tools: [query]\n  matchers: [namespace]\n"},
+		{name: "matchers with any tool", yaml: "policies:\n- name: x\n  principals: [alice]\n  tools: [\"*\"]\n  matchers: [namespace=\"team-a\"]\n"},
+		{name: "matchers with unrestricted tool", yaml: "policies:\n- name: x\n  principals: [alice]\n  tools: [query, metrics]\n  matchers: [namespace=\"team-a\"]\n"},
+	}
+	for _, test := range tests {
+		if _, err := load(t, test.yaml); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/DazWilkin/prometheus-mcp-server/errors"
+	"github.com/DazWilkin/prometheus-mcp-server/handlers"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin/*.yaml
+var builtin embed.FS
+
+// name is the pattern of prompt and argument names; argument names are also template field names
+var name = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Argument is a type that represents an argument of a prompt
+type Argument struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// Message is a type that represents a (templated) message of a prompt
+type Message struct {
+	// Role is either user (default) or assistant
+	Role mcp.Role `yaml:"role,omitempty"`
+	// Content is a text/template; arguments are fields e.g. {{ .alertname }}
+	Content string `yaml:"content"`
+
+	template *template.Template
+}
+
+// Prompt is a type that represents a prompt whose messages guide a model through a sequence of tools
+type Prompt struct {
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description,omitempty"`
+	Arguments   []Argument `yaml:"arguments,omitempty"`
+	// Tools are the names of the tools that the messages use
+	// The prompt is only published if the tools are published and may only be got by principals that may call the tools
+	Tools    []string  `yaml:"tools,omitempty"`
+	Messages []Message `yaml:"messages"`
+}
+
+// init is a method that validates the prompt and parses its messages' templates
+func (p *Prompt) init() error {
+	if !name.MatchString(p.Name) {
+		return fmt.Errorf("prompt name %q must match %s", p.Name, name)
+	}
+	for i, a := range p.Arguments {
+		if !name.MatchString(a.Name) {
+			return fmt.Errorf("prompt %q: argument name %q must match %s", p.Name, a.Name, name)
+		}
+		if slices.ContainsFunc(p.Arguments[:i], func(b Argument) bool {
+			return b.Name == a.Name
+		}) {
+			return fmt.Errorf("prompt %q: duplicate argument %q", p.Name, a.Name)
+		}
+	}
+	for _, tool := range p.Tools {
+		if !slices.ContainsFunc(slices.Collect(maps.Values(handlers.Groups)), func(tools []string) bool {
+			return slices.Contains(tools, tool)
+		}) {
+			return fmt.Errorf("prompt %q: unknown tool %q", p.Name, tool)
+		}
+	}
+	if len(p.Messages) == 0 {
+		return fmt.Errorf("prompt %q requires messages", p.Name)
+	}
+	for i := range p.Messages {
+		m := &p.Messages[i]
+		switch m.Role {
+		case "":
+			m.Role = mcp.RoleUser
+		case mcp.RoleUser, mcp.RoleAssistant:
+		default:
+			return fmt.Errorf("prompt %q: message %d: role %q must be %s or %s", p.Name, i, m.Role, mcp.RoleUser, mcp.RoleAssistant)
+		}
+		t, err := template.New(p.Name).Option("missingkey=error").Parse(m.Content)
+		if err != nil {
+			return fmt.Errorf("prompt %q: message %d: %w", p.Name, i, err)
+		}
+		m.template = t
+	}
+	return nil
+}
+
+// Render is a method that returns the prompt's messages with the arguments
+// Required arguments must be provided; omitted optional arguments are empty strings
+func (p *Prompt) Render(args map[string]string) ([]mcp.PromptMessage, error) {
+	data := make(map[string]string, len(p.Arguments))
+	for _, a := range p.Arguments {
+		v := strings.TrimSpace(args[a.Name])
+		if v == "" && a.Required {
+			return nil, fmt.Errorf("prompt %q requires argument %q", p.Name, a.Name)
+		}
+		data[a.Name] = v
+	}
+	for k := range args {
+		if _, ok := data[k]; !ok {
+			return nil, fmt.Errorf("prompt %q has no argument %q", p.Name, k)
+		}
+	}
+
+	messages := make([]mcp.PromptMessage, len(p.Messages))
+	for i, m := range p.Messages {
+		var b bytes.Buffer
+		if err := m.template.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("prompt %q: message %d: %w", p.Name, i, err)
+		}
+		messages[i] = mcp.NewPromptMessage(m.Role, mcp.NewTextContent(strings.TrimSpace(b.String())))
+	}
+	return messages, nil
+}
+
+// ServerPrompt is a method that returns the prompt as an MCP server prompt
+func (p *Prompt) ServerPrompt() server.ServerPrompt {
+	opts := []mcp.PromptOption{
+		mcp.WithPromptDescription(p.Description),
+	}
+	for _, a := range p.Arguments {
+		argOpts := []mcp.ArgumentOption{
+			mcp.ArgumentDescription(a.Description),
+		}
+		if a.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(a.Name, argOpts...))
+	}
+
+	return server.ServerPrompt{
+		Prompt: mcp.NewPrompt(p.Name, opts...),
+		Handler: func(ctx context.Context, rqst mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			messages, err := p.Render(rqst.Params.Arguments)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewGetPromptResult(p.Description, messages), nil
+		},
+	}
+}
+
+// HandlersPrompt is a method that returns the prompt as an MCP server prompt and the tools that it uses
+func (p *Prompt) HandlersPrompt() handlers.Prompt {
+	return handlers.Prompt{
+		ServerPrompt: p.ServerPrompt(),
+		Tools:        p.Tools,
+	}
+}
+
+// parse is a function that parses a prompt from YAML
+func parse(b []byte) (*Prompt, error) {
+	p := &Prompt{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	if err := p.init(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load is a function that loads the prompts (*.yaml, *.yml files) of a file system
+// Each file is a prompt; prompts' names must be unique
+func load(fsys fs.FS) ([]*Prompt, error) {
+	prompts := []*Prompt{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		p, err := parse(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if slices.ContainsFunc(prompts, func(q *Prompt) bool {
+			return q.Name == p.Name
+		}) {
+			return fmt.Errorf("%s: duplicate prompt %q", path, p.Name)
+		}
+		prompts = append(prompts, p)
+		return nil
+	})
+	return prompts, err
+}
+
+// Builtin is a function that returns the built-in prompts
+func Builtin() ([]*Prompt, error) {
+	sub, err := fs.Sub(builtin, "builtin")
+	if err != nil {
+		return nil, err
+	}
+	return load(sub)
+}
+
+// Load is a function that returns the built-in prompts and the prompts of a directory (if any) e.g.:
+//
+//	name: restart_runbook
+//	description: Check a job's targets before restarting it
+//	arguments:
+//	- name: job
+//	  required: true
+//	tools:
+//	- targets
+//	messages:
+//	- content: |
+//	    Use the targets tool to list the targets of the job {{ .job }}...
+//
+// A prompt of the directory replaces the built-in prompt of the same name
+func Load(dir string) ([]*Prompt, error) {
+	prompts, err := Builtin()
+	if err != nil {
+		msg := "unable to load built-in prompts"
+		return nil, errors.NewErrConfig(msg, err)
+	}
+	if dir == "" {
+		return prompts, nil
+	}
+
+	loaded, err := load(os.DirFS(dir))
+	if err != nil {
+		msg := fmt.Sprintf("unable to load prompts from %q", dir)
+		return nil, errors.NewErrConfig(msg, err)
+	}
+	for _, p := range loaded {
+		if i := slices.IndexFunc(prompts, func(q *Prompt) bool {
+			return q.Name == p.Name
+		}); i >= 0 {
+			prompts[i] = p
+			continue
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
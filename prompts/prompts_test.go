@@ -0,0 +1,219 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// write is a function that writes files to a (temporary) directory
+func write(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, s := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(s), 0o600); err != nil {
+			t.Fatalf("unable to write file: %+v", err)
+		}
+	}
+	return dir
+}
+
+// text is a function that returns the text of the prompt's messages
+func text(t *testing.T, messages []mcp.PromptMessage) string {
+	t.Helper()
+
+	texts := make([]string, len(messages))
+	for i, m := range messages {
+		content, ok := m.Content.(mcp.TextContent)
+		if !ok {
+			t.Fatalf("got: %T; want: mcp.TextContent", m.Content)
+		}
+		texts[i] = content.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TestBuiltin tests that the built-in prompts render their arguments and only reference the tools that they declare
+func TestBuiltin(t *testing.T) {
+	prompts, err := Builtin()
+	if err != nil {
+		t.Fatalf("unable to load built-in prompts: %+v", err)
+	}
+
+	got := []string{}
+	for _, p := range prompts {
+		got = append(got, p.Name)
+	}
+	slices.Sort(got)
+	want := []string{"cardinality_audit", "check_target_health", "explain_metric", "investigate_alert", "slo_burn"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got: %v; want: %v", got, want)
+	}
+
+	tool := regexp.MustCompile(`the ([a-z_]+) tool`)
+
+	tests := []struct {
+		name     string
+		args     map[string]string
+		ok       bool
+		contains []string
+	}{
+		{name: "investigate_alert", args: map[string]string{"alertname": "NodeDown"}, ok: true, contains: []string{`alertname="NodeDown"`, "alerting rule named NodeDown"}},
+		{name: "investigate_alert"},
+		{name: "investigate_alert", args: map[string]string{"alertname": " "}},
+		{name: "investigate_alert", args: map[string]string{"alertname": "NodeDown", "job": "node"}},
+		{name: "explain_metric", args: map[string]string{"name": "up"}, ok: true, contains: []string{`metric="up"`}},
+		{name: "check_target_health", args: map[string]string{"job": "node"}, ok: true, contains: []string{`up{job="node"}`}},
+		{name: "slo_burn", args: map[string]string{"service": "api"}, ok: true, contains: []string{"objective of 99.9% over 30d", "1 - 99.9/100"}},
+		{name: "slo_burn", args: map[string]string{"service": "api", "objective": "99.5", "window": "7d"}, ok: true, contains: []string{"objective of 99.5% over 7d"}},
+		{name: "cardinality_audit", ok: true, contains: []string{"top 10", "topk(10,"}},
+		{name: "cardinality_audit", args: map[string]string{"limit": "5"}, ok: true, contains: []string{"topk(5,"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := slices.IndexFunc(prompts, func(p *Prompt) bool {
+				return p.Name == test.name
+			})
+			if i < 0 {
+				t.Fatalf("expected prompt %q", test.name)
+			}
+
+			messages, err := prompts[i].Render(test.args)
+			if (err == nil) != test.ok {
+				t.Fatalf("got: %v; want ok: %t", err, test.ok)
+			}
+			if !test.ok {
+				return
+			}
+
+			s := text(t, messages)
+			if strings.Contains(s, "<no value>") || strings.HasPrefix(s, "\n") {
+				t.Errorf("got: %q; want: rendered prompt", s)
+			}
+			for _, want := range test.contains {
+				if !strings.Contains(s, want) {
+					t.Errorf("got: %s; want: %s", s, want)
+				}
+			}
+			for _, match := range tool.FindAllStringSubmatch(s, -1) {
+				if !slices.Contains(prompts[i].Tools, match[1]) {
+					t.Errorf("prompt references undeclared tool %q", match[1])
+				}
+			}
+		})
+	}
+}
+
+// TestLoad tests that prompts are loaded from a directory and replace built-in prompts of the same name
+func TestLoad(t *testing.T) {
+	dir := write(t, map[string]string{
+		"restart.yaml": `name: restart_runbook
+description: Check a job's targets before restarting it
+arguments:
+- name: job
+  required: true
+tools:
+- targets
+messages:
+- content: Use the targets tool to list the targets of the job {{ .job }}
+- role: assistant
+  content: I'll check the targets of {{ .job }}
+`,
+		"alert.yml": `name: investigate_alert
+arguments:
+- name: alertname
+  required: true
+messages:
+- content: Follow the runbook for {{ .alertname }}
+`,
+		"README.md": "Not a prompt",
+	})
+
+	prompts, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unable to load prompts: %+v", err)
+	}
+	if len(prompts) != 6 {
+		t.Errorf("got: %d prompts; want: 6", len(prompts))
+	}
+
+	{
+		i := slices.IndexFunc(prompts, func(p *Prompt) bool {
+			return p.Name == "investigate_alert"
+		})
+		messages, err := prompts[i].Render(map[string]string{"alertname": "NodeDown"})
+		if err != nil {
+			t.Fatalf("unable to render prompt: %+v", err)
+		}
+		if got, want := text(t, messages), "Follow the runbook for NodeDown"; got != want {
+			t.Errorf("got: %q; want: %q", got, want)
+		}
+	}
+
+	// The prompt is served using MCP
+	{
+		i := slices.IndexFunc(prompts, func(p *Prompt) bool {
+			return p.Name == "restart_runbook"
+		})
+		sp := prompts[i].ServerPrompt()
+		if len(sp.Prompt.Arguments) != 1 || !sp.Prompt.Arguments[0].Required {
+			t.Errorf("got: %+v; want: 1 required argument", sp.Prompt.Arguments)
+		}
+		if got := prompts[i].HandlersPrompt().Tools; !slices.Equal(got, []string{"targets"}) {
+			t.Errorf("got: %v; want: [targets]", got)
+		}
+
+		rqst := mcp.GetPromptRequest{}
+		rqst.Params.Name = "restart_runbook"
+		rqst.Params.Arguments = map[string]string{"job": "node"}
+		resp, err := sp.Handler(context.Background(), rqst)
+		if err != nil {
+			t.Fatalf("unable to get prompt: %+v", err)
+		}
+		if resp.Description != "Check a job's targets before restarting it" {
+			t.Errorf("got: %q", resp.Description)
+		}
+		if len(resp.Messages) != 2 || resp.Messages[0].Role != mcp.RoleUser || resp.Messages[1].Role != mcp.RoleAssistant {
+			t.Errorf("got: %+v; want: user and assistant messages", resp.Messages)
+		}
+	}
+}
+
+// TestLoadInvalid tests that invalid prompts aren't loaded
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "yaml", files: map[string]string{"a.yaml": "name: [a"}},
+		{name: "name", files: map[string]string{"a.yaml": "name: my-prompt\nmessages:\n- content: x\n"}},
+		{name: "argument", files: map[string]string{"a.yaml": "name: a\narguments:\n- name: alert.name\nmessages:\n- content: x\n"}},
+		{name: "duplicate argument", files: map[string]string{"a.yaml": "name: a\narguments:\n- name: x\n- name: x\nmessages:\n- content: x\n"}},
+		{name: "tool", files: map[string]string{"a.yaml": "name: a\ntools:\n- query\n- queries\nmessages:\n- content: x\n"}},
+		{name: "messages", files: map[string]string{"a.yaml": "name: a\n"}},
+		{name: "role", files: map[string]string{"a.yaml": "name: a\nmessages:\n- role: system\n  content: x\n"}},
+		{name: "template", files: map[string]string{"a.yaml": "name: a\nmessages:\n- content: \"{{ .x \"\n"}},
+		{name: "duplicate prompt", files: map[string]string{
+			"a.yaml": "name: a\nmessages:\n- content: x\n",
+			"b.yaml": "name: a\nmessages:\n- content: y\n",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Load(write(t, test.files)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected error")
+	}
+}
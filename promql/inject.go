@@ -0,0 +1,53 @@
+package promql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// ParseMatchers is a function that parses label matchers e.g. namespace="team-a", env=~"prod|staging"
+func ParseMatchers(ss []string) ([]*labels.Matcher, error) {
+	matchers := []*labels.Matcher{}
+	for _, s := range ss {
+		mm, err := parser.ParseMetricSelector("{" + s + "}")
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse label matcher %q: %w", s, err)
+		}
+		matchers = append(matchers, mm...)
+	}
+	return matchers, nil
+}
+
+// Inject is a function that adds the label matchers to every (instant and range) vector selector of a PromQL expression
+// Existing matchers are preserved so that the selectors match (at most) the series that the injected matchers match
+func Inject(query string, matchers []*labels.Matcher) (string, error) {
+	if len(matchers) == 0 {
+		return query, nil
+	}
+
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", err
+	}
+
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			vs.LabelMatchers = append(vs.LabelMatchers, matchers...)
+		}
+		return nil
+	})
+
+	return expr.String(), nil
+}
+
+// MatchersSelector is a function that returns the (vector) selector of the label matchers e.g. {namespace="team-a"}
+func MatchersSelector(matchers []*labels.Matcher) string {
+	ss := make([]string, len(matchers))
+	for i, m := range matchers {
+		ss[i] = m.String()
+	}
+	return "{" + strings.Join(ss, ",") + "}"
+}
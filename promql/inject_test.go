@@ -0,0 +1,56 @@
+package promql
+
+import (
+	"testing"
+)
+
+// TestInject tests that label matchers are added to every vector selector
+func TestInject(t *testing.T) {
+	matchers, err := ParseMatchers([]string{`namespace="team-a"`})
+	if err != nil {
+		t.Fatalf("unable to parse matchers: %+v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `up`, want: `up{namespace="team-a"}`},
+		{query: `{__name__="up"}`, want: `{__name__="up",namespace="team-a"}`},
+		{query: `rate(http_requests_total{code=~"5.."}[5m])`, want: `rate(http_requests_total{code=~"5..",namespace="team-a"}[5m])`},
+		{query: `sum(up) / count(up offset 1h)`, want: `sum(up{namespace="team-a"}) / count(up{namespace="team-a"} offset 1h)`},
+		{query: `max_over_time(rate(up[5m])[1h:5m])`, want: `max_over_time(rate(up{namespace="team-a"}[5m])[1h:5m])`},
+		// Existing matchers are preserved so that other namespaces match nothing
+		{query: `up{namespace="team-b"}`, want: `up{namespace="team-a",namespace="team-b"}`},
+		{query: `vector(1)`, want: `vector(1)`},
+	}
+	for _, test := range tests {
+		got, err := Inject(test.query, matchers)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %+v", test.query, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("got: %s; want: %s", got, test.want)
+		}
+	}
+
+	if _, err := Inject(`up{`, matchers); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestParseMatchers tests ParseMatchers
+func TestParseMatchers(t *testing.T) {
+	matchers, err := ParseMatchers([]string{`namespace="team-a"`, `env=~"prod|staging"`})
+	if err != nil {
+		t.Fatalf("unable to parse matchers: %+v", err)
+	}
+	if got, want := MatchersSelector(matchers), `{namespace="team-a",env=~"prod|staging"}`; got != want {
+		t.Errorf("got: %s; want: %s", got, want)
+	}
+
+	if _, err := ParseMatchers([]string{`namespace`}); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,390 @@
+package promql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	SeverityWarning string = "warning"
+	SeverityInfo    string = "info"
+)
+
+const (
+	// irate only uses the last two samples in the range
+	// Ranges longer than this (or 10 scrape intervals) don't change the result but suggest a misunderstanding
+	irateMaxRange time.Duration = 5 * time.Minute
+)
+
+var (
+	// Functions that expect counters
+	counterFunctions = []string{"increase", "irate", "rate", "resets"}
+	// Functions that expect gauges
+	gaugeFunctions = []string{"delta", "deriv", "idelta", "predict_linear"}
+	// Aggregations that (without grouping) aggregate away all labels
+	groupingAggregations = []string{"avg", "count", "group", "max", "min", "stddev", "stdvar", "sum"}
+	// Suffixes of the series that comprise histograms and summaries
+	counterSuffixes = []string{"_bucket", "_count", "_sum", "_total"}
+)
+
+// Warning is a type that represents a lint finding in a (syntactically valid) PromQL expression
+type Warning struct {
+	Rule       string `json:"rule"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	Expression string `json:"expression"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// LintInfo is a type that represents information about metrics and targets that improves linting
+// Both maps may be empty in which case lint relies on naming conventions
+type LintInfo struct {
+	// Types maps metric (family) names to their type (counter, gauge, histogram etc.)
+	Types map[string]string
+	// ScrapeIntervals maps job names to their scrape interval
+	ScrapeIntervals map[string]time.Duration
+}
+
+// Linting is a type that represents the result of linting a PromQL expression
+type Linting struct {
+	Valid    bool          `json:"valid"`
+	Errors   []SyntaxError `json:"errors,omitempty"`
+	Warnings []Warning     `json:"warnings"`
+}
+
+// LintQuery is a function that parses and, if valid, lints a PromQL expression
+func LintQuery(query string, info LintInfo) *Linting {
+	expr, err := Parse(query)
+	if err != nil {
+		return &Linting{
+			Valid:    false,
+			Errors:   SyntaxErrors(query, err),
+			Warnings: []Warning{},
+		}
+	}
+
+	return &Linting{
+		Valid:    true,
+		Warnings: Lint(expr, info),
+	}
+}
+
+// Lint is a function that identifies common mistakes in a parsed PromQL expression
+func Lint(expr parser.Expr, info LintInfo) []Warning {
+	warnings := []Warning{}
+
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.Call:
+			warnings = append(warnings, lintCall(n, info)...)
+		case *parser.AggregateExpr:
+			warnings = append(warnings, lintAggregate(n, path)...)
+		}
+		return nil
+	})
+
+	return warnings
+}
+
+// lintCall is a function that lints function calls
+func lintCall(call *parser.Call, info LintInfo) []Warning {
+	warnings := []Warning{}
+	name := call.Func.Name
+
+	if name == "histogram_quantile" {
+		warnings = append(warnings, lintHistogramQuantile(call)...)
+	}
+
+	// The remaining rules apply to functions with a range vector selector argument
+	ms := matrixArgument(call)
+	if ms == nil {
+		return warnings
+	}
+	vs, ok := ms.VectorSelector.(*parser.VectorSelector)
+	if !ok {
+		return warnings
+	}
+
+	metric := metricName(vs)
+	typ := MetricType(metric, info.Types)
+
+	if slices.Contains(counterFunctions, name) && isGauge(typ) {
+		alternative := "deriv"
+		if name == "increase" {
+			alternative = "delta"
+		}
+		warnings = append(warnings, Warning{
+			Rule:       "counter-function-on-gauge",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s() expects a counter but %s is a %s; counter resets will be misinterpreted", name, metric, typ),
+			Expression: call.String(),
+			Suggestion: fmt.Sprintf("%s(%s)", alternative, ms.String()),
+		})
+	}
+
+	if slices.Contains(gaugeFunctions, name) && typ == string(model.MetricTypeCounter) {
+		alternative := "rate"
+		if name == "delta" {
+			alternative = "increase"
+		}
+		warnings = append(warnings, Warning{
+			Rule:       "gauge-function-on-counter",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s() should only be used with gauges but %s is a counter; counter resets aren't handled", name, metric),
+			Expression: call.String(),
+			Suggestion: fmt.Sprintf("%s(%s)", alternative, ms.String()),
+		})
+	}
+
+	// Duration expressions (e.g. [5m+1m]) aren't evaluated
+	if ms.RangeExpr != nil || ms.Range == 0 {
+		return warnings
+	}
+
+	interval, known := scrapeInterval(vs, info.ScrapeIntervals)
+
+	if name == "irate" {
+		limit := irateMaxRange
+		if known {
+			limit = max(limit, 10*interval)
+		}
+		if ms.Range > limit {
+			warnings = append(warnings, Warning{
+				Rule:       "irate-long-range",
+				Severity:   SeverityInfo,
+				Message:    fmt.Sprintf("irate() only uses the last two samples in the range; a %s range doesn't smooth the result", model.Duration(ms.Range)),
+				Expression: call.String(),
+				Suggestion: fmt.Sprintf("rate(%s)", ms.String()),
+			})
+		}
+	}
+
+	// Functions other than *_over_time generally need at least 2 samples
+	// So the range should be at least 2 (recommended 4) scrape intervals
+	if known && ms.Range < 2*interval && !strings.HasSuffix(name, "_over_time") {
+		suggested := *ms
+		suggested.Range = 4 * interval
+		warnings = append(warnings, Warning{
+			Rule:     "range-shorter-than-scrape-interval",
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("the %s range contains fewer than 2 samples at the %s scrape interval; %s() may return no results",
+				model.Duration(ms.Range),
+				model.Duration(interval),
+				name,
+			),
+			Expression: call.String(),
+			Suggestion: fmt.Sprintf("%s(%s)", name, suggested.String()),
+		})
+	}
+
+	return warnings
+}
+
+// lintHistogramQuantile is a function that lints histogram_quantile calls
+// Classic histograms must preserve the "le" label when aggregated
+func lintHistogramQuantile(call *parser.Call) []Warning {
+	if len(call.Args) != 2 {
+		return nil
+	}
+
+	agg := firstAggregate(call.Args[1])
+	if agg == nil {
+		return nil
+	}
+
+	// Native histograms don't have "le"
+	// These are only identifiable by the absence of the _bucket suffix
+	bucket := false
+	parser.Inspect(agg, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok && strings.HasSuffix(metricName(vs), "_bucket") {
+			bucket = true
+		}
+		return nil
+	})
+	if !bucket {
+		return nil
+	}
+
+	preserved := slices.Contains(agg.Grouping, "le") != agg.Without
+	if preserved {
+		return nil
+	}
+
+	suggested := *agg
+	if agg.Without {
+		suggested.Grouping = slices.DeleteFunc(slices.Clone(agg.Grouping), func(s string) bool { return s == "le" })
+	} else {
+		suggested.Grouping = append(slices.Clone(agg.Grouping), "le")
+	}
+
+	return []Warning{
+		{
+			Rule:       "histogram-quantile-without-le",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("histogram_quantile() requires the 'le' label but %s() aggregates it away", agg.Op),
+			Expression: call.String(),
+			Suggestion: fmt.Sprintf("histogram_quantile(%s, %s)", call.Args[0], suggested.String()),
+		},
+	}
+}
+
+// lintAggregate is a function that lints aggregations
+func lintAggregate(agg *parser.AggregateExpr, path []parser.Node) []Warning {
+	op := agg.Op.String()
+	if !slices.Contains(groupingAggregations, op) || agg.Without || len(agg.Grouping) != 0 {
+		return nil
+	}
+
+	// histogram_quantile is linted separately
+	for _, node := range path {
+		if call, ok := node.(*parser.Call); ok && call.Func.Name == "histogram_quantile" {
+			return nil
+		}
+	}
+
+	return []Warning{
+		{
+			Rule:       "aggregation-without-grouping",
+			Severity:   SeverityInfo,
+			Message:    fmt.Sprintf("%s() without 'by' aggregates away all labels; the result is a single series", op),
+			Expression: agg.String(),
+			Suggestion: fmt.Sprintf("%s by (job) (%s)", op, agg.Expr),
+		},
+	}
+}
+
+// matrixArgument is a function that returns a call's range vector selector argument (if any)
+func matrixArgument(call *parser.Call) *parser.MatrixSelector {
+	for i, t := range call.Func.ArgTypes {
+		if t != parser.ValueTypeMatrix || i >= len(call.Args) {
+			continue
+		}
+		if ms, ok := unwrap(call.Args[i]).(*parser.MatrixSelector); ok {
+			return ms
+		}
+	}
+	return nil
+}
+
+// firstAggregate is a function that returns the outermost aggregation within an expression
+func firstAggregate(expr parser.Expr) *parser.AggregateExpr {
+	var result *parser.AggregateExpr
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if agg, ok := node.(*parser.AggregateExpr); ok && result == nil {
+			result = agg
+		}
+		return nil
+	})
+	return result
+}
+
+// unwrap is a function that removes parentheses (and step invariants) from an expression
+func unwrap(expr parser.Expr) parser.Expr {
+	for {
+		switch e := expr.(type) {
+		case *parser.ParenExpr:
+			expr = e.Expr
+		case *parser.StepInvariantExpr:
+			expr = e.Expr
+		default:
+			return expr
+		}
+	}
+}
+
+// metricName is a function that returns a vector selector's metric name
+func metricName(vs *parser.VectorSelector) string {
+	if vs.Name != "" {
+		return vs.Name
+	}
+	for _, m := range vs.LabelMatchers {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// MetricType is a function that determines the type of a metric using metadata and naming conventions
+// The series that comprise histograms and summaries (e.g. _bucket, _count) are counters
+// It returns "" if the type can't be determined
+func MetricType(metric string, types map[string]string) string {
+	if metric == "" {
+		return ""
+	}
+
+	if t, ok := types[metric]; ok && t != string(model.MetricTypeUnknown) {
+		return t
+	}
+
+	for _, suffix := range counterSuffixes {
+		base, ok := strings.CutSuffix(metric, suffix)
+		if !ok {
+			continue
+		}
+		switch t := types[base]; t {
+		case string(model.MetricTypeGaugeHistogram):
+			return string(model.MetricTypeGauge)
+		case string(model.MetricTypeHistogram), string(model.MetricTypeSummary), string(model.MetricTypeCounter):
+			return string(model.MetricTypeCounter)
+		}
+		// Without metadata, rely on naming conventions
+		return string(model.MetricTypeCounter)
+	}
+
+	return ""
+}
+
+// isGauge is a function that determines whether a metric type is a gauge
+func isGauge(typ string) bool {
+	return typ == string(model.MetricTypeGauge)
+}
+
+// ScrapeInterval is a function that determines the (longest) scrape interval of the targets selected by an expression
+// It returns false if the scrape interval of none of the expression's selectors is known
+func ScrapeInterval(expr parser.Expr, intervals map[string]time.Duration) (time.Duration, bool) {
+	var result time.Duration
+	found := false
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			if interval, ok := scrapeInterval(vs, intervals); ok {
+				result = max(result, interval)
+				found = true
+			}
+		}
+		return nil
+	})
+	return result, found
+}
+
+// scrapeInterval is a function that determines the scrape interval of the targets selected by a vector selector
+// If the selector includes job="...", that job's interval is used
+// Otherwise the interval is only known if all jobs share the same interval
+func scrapeInterval(vs *parser.VectorSelector, intervals map[string]time.Duration) (time.Duration, bool) {
+	if len(intervals) == 0 {
+		return 0, false
+	}
+
+	for _, m := range vs.LabelMatchers {
+		if m.Name == "job" && m.Type == labels.MatchEqual {
+			interval, ok := intervals[m.Value]
+			return interval, ok
+		}
+	}
+
+	var result time.Duration
+	for _, interval := range intervals {
+		if result != 0 && interval != result {
+			return 0, false
+		}
+		result = interval
+	}
+
+	return result, true
+}
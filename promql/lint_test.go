@@ -0,0 +1,183 @@
+package promql
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLintQuery tests LintQuery's rules
+func TestLintQuery(t *testing.T) {
+	info := LintInfo{
+		Types: map[string]string{
+			"node_memory_MemFree_bytes":     "gauge",
+			"http_requests_total":           "counter",
+			"http_request_duration_seconds": "histogram",
+		},
+		ScrapeIntervals: map[string]time.Duration{
+			"node":       30 * time.Second,
+			"prometheus": 15 * time.Second,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		// Expected rules (in order)
+		want []string
+	}{
+		{
+			name:  "valid",
+			query: `sum by (job) (rate(http_requests_total{job="prometheus"}[1m]))`,
+			want:  []string{},
+		},
+		{
+			name:  "rate on gauge",
+			query: `rate(node_memory_MemFree_bytes{job="node"}[5m])`,
+			want:  []string{"counter-function-on-gauge"},
+		},
+		{
+			name:  "deriv on counter",
+			query: `deriv(http_requests_total{job="prometheus"}[5m])`,
+			want:  []string{"gauge-function-on-counter"},
+		},
+		{
+			name:  "sum without by",
+			query: `sum(rate(http_requests_total{job="prometheus"}[5m]))`,
+			want:  []string{"aggregation-without-grouping"},
+		},
+		{
+			name:  "histogram_quantile without le",
+			query: `histogram_quantile(0.9, sum by (job) (rate(http_request_duration_seconds_bucket{job="prometheus"}[5m])))`,
+			want:  []string{"histogram-quantile-without-le"},
+		},
+		{
+			name:  "histogram_quantile with le",
+			query: `histogram_quantile(0.9, sum by (job, le) (rate(http_request_duration_seconds_bucket{job="prometheus"}[5m])))`,
+			want:  []string{},
+		},
+		{
+			name:  "histogram_quantile without (le)",
+			query: `histogram_quantile(0.9, sum without (le) (rate(http_request_duration_seconds_bucket{job="prometheus"}[5m])))`,
+			want:  []string{"histogram-quantile-without-le"},
+		},
+		{
+			name:  "irate over long range",
+			query: `irate(http_requests_total{job="prometheus"}[1h])`,
+			want:  []string{"irate-long-range"},
+		},
+		{
+			name:  "range shorter than scrape interval",
+			query: `rate(http_requests_total{job="node"}[30s])`,
+			want:  []string{"range-shorter-than-scrape-interval"},
+		},
+		{
+			name:  "*_over_time with range of scrape interval",
+			query: `max_over_time(node_memory_MemFree_bytes{job="node"}[30s])`,
+			want:  []string{},
+		},
+		{
+			name:  "unknown job",
+			query: `rate(http_requests_total{job="unknown"}[10s])`,
+			want:  []string{},
+		},
+		{
+			name:  "naming conventions",
+			query: `rate(foo_total{job="prometheus"}[1m])`,
+			want:  []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := LintQuery(test.query, info)
+			if !l.Valid {
+				t.Fatalf("expected valid: %+v", l.Errors)
+			}
+
+			got := []string{}
+			for _, w := range l.Warnings {
+				t.Logf("Warning: %+v", w)
+				got = append(got, w.Rule)
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got: %+q; want: %+q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("got: %+q; want: %+q", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// TestLintQuerySuggestion tests that suggestions are valid PromQL
+func TestLintQuerySuggestion(t *testing.T) {
+	l := LintQuery(`histogram_quantile(0.9, sum by (job) (rate(http_request_duration_seconds_bucket[5m])))`, LintInfo{})
+	if len(l.Warnings) != 1 {
+		t.Fatalf("got: %d warnings; want: 1", len(l.Warnings))
+	}
+
+	got := l.Warnings[0].Suggestion
+	want := `histogram_quantile(0.9, sum by (job, le) (rate(http_request_duration_seconds_bucket[5m])))`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if v := Validate(got); !v.Valid {
+		t.Errorf("expected suggestion to be valid: %+v", v.Errors)
+	}
+}
+
+// TestMetricType tests MetricType
+func TestMetricType(t *testing.T) {
+	types := map[string]string{
+		"go_goroutines":                 "gauge",
+		"http_request_duration_seconds": "histogram",
+	}
+
+	tests := map[string]string{
+		"go_goroutines":                        "gauge",
+		"http_request_duration_seconds_bucket": "counter",
+		"http_request_duration_seconds_count":  "counter",
+		"foo_total":                            "counter",
+		"foo":                                  "",
+	}
+	for metric, want := range tests {
+		if got := MetricType(metric, types); got != want {
+			t.Errorf("%s: got: %q; want: %q", metric, got, want)
+		}
+	}
+}
+
+// TestScrapeInterval tests ScrapeInterval
+func TestScrapeInterval(t *testing.T) {
+	intervals := map[string]time.Duration{
+		"node":       30 * time.Second,
+		"prometheus": 15 * time.Second,
+	}
+
+	tests := []struct {
+		query string
+		want  time.Duration
+		ok    bool
+	}{
+		{query: `up{job="prometheus"}`, want: 15 * time.Second, ok: true},
+		{query: `up{job="prometheus"} / on(instance) up{job="node"}`, want: 30 * time.Second, ok: true},
+		{query: `up{job="unknown"}`, want: 0, ok: false},
+		// Jobs don't share an interval
+		{query: `up`, want: 0, ok: false},
+		{query: `vector(1)`, want: 0, ok: false},
+	}
+	for _, test := range tests {
+		expr, err := Parse(test.query)
+		if err != nil {
+			t.Fatalf("unable to parse %s: %+v", test.query, err)
+		}
+
+		got, ok := ScrapeInterval(expr, intervals)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: got: %s, %t; want: %s, %t", test.query, got, ok, test.want, test.ok)
+		}
+	}
+}
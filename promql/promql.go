@@ -0,0 +1,238 @@
+package promql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// SyntaxError is a type that represents a position-annotated PromQL syntax error
+type SyntaxError struct {
+	Message string `json:"message"`
+	// Line and Column are 1-based
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Start and End are 0-based (byte) offsets into the expression
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Context is the line of the expression containing the error with a caret (^) marking the position
+	Context string `json:"context"`
+}
+
+// String is a method that returns a string
+func (e SyntaxError) String() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Selector is a type that represents a PromQL (instant or range) vector selector
+type Selector struct {
+	Selector string   `json:"selector"`
+	Metric   string   `json:"metric,omitempty"`
+	Matchers []string `json:"matchers,omitempty"`
+	Range    string   `json:"range,omitempty"`
+	Offset   string   `json:"offset,omitempty"`
+}
+
+// Analysis is a type that represents a summary of a parsed PromQL expression
+type Analysis struct {
+	Expression   string     `json:"expression"`
+	Type         string     `json:"type"`
+	Metrics      []string   `json:"metrics"`
+	Selectors    []Selector `json:"selectors"`
+	Functions    []string   `json:"functions"`
+	Aggregations []string   `json:"aggregations"`
+}
+
+// Validation is a type that represents the result of validating a PromQL expression
+type Validation struct {
+	Valid  bool          `json:"valid"`
+	Errors []SyntaxError `json:"errors,omitempty"`
+	*Analysis
+}
+
+// Parse is a function that parses a PromQL expression
+func Parse(query string) (parser.Expr, error) {
+	return parser.ParseExpr(query)
+}
+
+// SyntaxErrors is a function that converts errors returned by Parse into SyntaxErrors
+func SyntaxErrors(query string, err error) []SyntaxError {
+	var errs parser.ParseErrors
+	if !errors.As(err, &errs) {
+		// Not a parse error; the best that can be done is to report its message
+		return []SyntaxError{
+			{
+				Message: err.Error(),
+				Line:    1,
+				Column:  1,
+			},
+		}
+	}
+
+	result := make([]SyntaxError, len(errs))
+	for i, e := range errs {
+		start := int(e.PositionRange.Start)
+		end := int(e.PositionRange.End)
+		line, column, context := locate(query, start)
+		result[i] = SyntaxError{
+			Message: e.Err.Error(),
+			Line:    line,
+			Column:  column,
+			Start:   start,
+			End:     end,
+			Context: context,
+		}
+	}
+
+	return result
+}
+
+// locate is a function that converts an offset into the expression into a 1-based line and column
+// It also returns the line containing the offset with a caret (^) marking the column
+func locate(query string, offset int) (int, int, string) {
+	offset = max(0, min(offset, len(query)))
+
+	// Lines preceding the offset
+	before := query[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+
+	lineEnd := strings.Index(query[lineStart:], "\n")
+	if lineEnd == -1 {
+		lineEnd = len(query)
+	} else {
+		lineEnd += lineStart
+	}
+
+	column := offset - lineStart + 1
+	context := fmt.Sprintf("%s\n%s^",
+		query[lineStart:lineEnd],
+		strings.Repeat(" ", column-1),
+	)
+
+	return line, column, context
+}
+
+// Analyze is a function that summarizes a parsed PromQL expression
+func Analyze(expr parser.Expr) *Analysis {
+	metrics := []string{}
+	selectors := []Selector{}
+	functions := []string{}
+	aggregations := []string{}
+
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			selector := newSelector(n)
+			// If the selector's parent is a matrix selector, it's a range vector selector
+			if len(path) != 0 {
+				if m, ok := path[len(path)-1].(*parser.MatrixSelector); ok {
+					selector.Selector = m.String()
+					selector.Range = model.Duration(m.Range).String()
+				}
+			}
+			selectors = append(selectors, selector)
+			if selector.Metric != "" && !slices.Contains(metrics, selector.Metric) {
+				metrics = append(metrics, selector.Metric)
+			}
+		case *parser.Call:
+			if !slices.Contains(functions, n.Func.Name) {
+				functions = append(functions, n.Func.Name)
+			}
+		case *parser.AggregateExpr:
+			op := n.Op.String()
+			if !slices.Contains(aggregations, op) {
+				aggregations = append(aggregations, op)
+			}
+		}
+		return nil
+	})
+
+	slices.Sort(metrics)
+	slices.Sort(functions)
+	slices.Sort(aggregations)
+
+	return &Analysis{
+		Expression:   expr.String(),
+		Type:         string(expr.Type()),
+		Metrics:      metrics,
+		Selectors:    selectors,
+		Functions:    functions,
+		Aggregations: aggregations,
+	}
+}
+
+// newSelector is a function that creates a Selector from a vector selector
+func newSelector(vs *parser.VectorSelector) Selector {
+	metric := vs.Name
+	matchers := []string{}
+	for _, m := range vs.LabelMatchers {
+		if m.Name == labels.MetricName {
+			// The metric name may be provided as a matcher e.g. {__name__="up"}
+			if metric == "" && m.Type == labels.MatchEqual {
+				metric = m.Value
+			}
+			// Only report __name__ matchers that aren't the selector's (implicit) metric name
+			if m.Type == labels.MatchEqual && m.Value == vs.Name {
+				continue
+			}
+		}
+		matchers = append(matchers, m.String())
+	}
+
+	selector := Selector{
+		Selector: vs.String(),
+		Metric:   metric,
+		Matchers: matchers,
+	}
+	if vs.OriginalOffset != 0 {
+		selector.Offset = model.Duration(vs.OriginalOffset).String()
+	}
+
+	return selector
+}
+
+// Format is a function that parses and pretty-prints a PromQL expression
+func Format(query string) (string, error) {
+	expr, err := Parse(query)
+	if err != nil {
+		return "", err
+	}
+
+	return parser.Prettify(expr), nil
+}
+
+// Validate is a function that parses and, if valid, summarizes a PromQL expression
+func Validate(query string) *Validation {
+	expr, err := Parse(query)
+	if err != nil {
+		return &Validation{
+			Valid:  false,
+			Errors: SyntaxErrors(query, err),
+		}
+	}
+
+	return &Validation{
+		Valid:    true,
+		Analysis: Analyze(expr),
+	}
+}
+
+// Err is a method that returns the validation's errors as a single error
+// It returns nil if the expression is valid
+func (v *Validation) Err() error {
+	if v.Valid {
+		return nil
+	}
+
+	ss := make([]string, len(v.Errors))
+	for i, e := range v.Errors {
+		ss[i] = e.String()
+	}
+	return errors.New(strings.Join(ss, "; "))
+}
@@ -0,0 +1,132 @@
+package promql
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestValidate tests Validate with valid expressions
+func TestValidate(t *testing.T) {
+	query := `sum by (job) (rate(http_requests_total{job="api",code=~"5.."}[5m])) / on (job) group_left sum by (job) (rate(http_requests_total[5m] offset 1h))`
+
+	v := Validate(query)
+	if !v.Valid {
+		t.Fatalf("expected valid: %+v", v.Errors)
+	}
+
+	if v.Type != "vector" {
+		t.Errorf("got: %s; want: vector", v.Type)
+	}
+
+	if want := []string{"http_requests_total"}; !slices.Equal(v.Metrics, want) {
+		t.Errorf("got: %+q; want: %+q", v.Metrics, want)
+	}
+
+	if want := []string{"rate"}; !slices.Equal(v.Functions, want) {
+		t.Errorf("got: %+q; want: %+q", v.Functions, want)
+	}
+
+	if want := []string{"sum"}; !slices.Equal(v.Aggregations, want) {
+		t.Errorf("got: %+q; want: %+q", v.Aggregations, want)
+	}
+
+	if len(v.Selectors) != 2 {
+		t.Fatalf("got: %d selectors; want: 2", len(v.Selectors))
+	}
+
+	{
+		got := v.Selectors[0]
+		if got.Range != "5m" {
+			t.Errorf("got: %s; want: 5m", got.Range)
+		}
+		if want := []string{`job="api"`, `code=~"5.."`}; !slices.Equal(got.Matchers, want) {
+			t.Errorf("got: %+q; want: %+q", got.Matchers, want)
+		}
+	}
+	{
+		got := v.Selectors[1]
+		if got.Offset != "1h" {
+			t.Errorf("got: %s; want: 1h", got.Offset)
+		}
+	}
+}
+
+// TestValidateMetricNameMatcher tests that metric names provided as __name__ matchers are reported
+func TestValidateMetricNameMatcher(t *testing.T) {
+	v := Validate(`{__name__="up",job="prometheus"}`)
+	if !v.Valid {
+		t.Fatalf("expected valid: %+v", v.Errors)
+	}
+
+	if want := []string{"up"}; !slices.Equal(v.Metrics, want) {
+		t.Errorf("got: %+q; want: %+q", v.Metrics, want)
+	}
+}
+
+// TestValidateSyntaxError tests Validate with invalid expressions
+func TestValidateSyntaxError(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		line   int
+		column int
+	}{
+		{
+			name:   "unclosed",
+			query:  `sum(rate(up[5m])`,
+			line:   1,
+			column: 17,
+		},
+		{
+			name:   "multiline",
+			query:  "sum(\n  rate(up[5m]) +\n)",
+			line:   3,
+			column: 1,
+		},
+		{
+			name:   "unknown function",
+			query:  `foo(up)`,
+			line:   1,
+			column: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := Validate(test.query)
+			if v.Valid {
+				t.Fatal("expected invalid")
+			}
+			if v.Err() == nil {
+				t.Error("expected error")
+			}
+
+			got := v.Errors[0]
+			t.Logf("Error: %s\n%s", got, got.Context)
+
+			if got.Line != test.line || got.Column != test.column {
+				t.Errorf("got: %d:%d; want: %d:%d", got.Line, got.Column, test.line, test.column)
+			}
+		})
+	}
+}
+
+// TestFormat tests Format
+func TestFormat(t *testing.T) {
+	query := `sum by (job) (rate(http_requests_total{job="api",code=~"5.."}[5m])) / on (job) group_left sum by (job) (rate(http_requests_total{job="api"}[5m]))`
+	want := `  sum by (job) (rate(http_requests_total{code=~"5..",job="api"}[5m]))
+/ on (job) group_left ()
+  sum by (job) (rate(http_requests_total{job="api"}[5m]))`
+
+	got, err := Format(query)
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := Format("sum(rate(up[5m])"); err == nil {
+		t.Error("expected error")
+	}
+}
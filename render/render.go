@@ -0,0 +1,386 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// Format is a type that represents an output format
+type Format string
+
+const (
+	JSON     Format = "json"
+	Table    Format = "table"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+)
+
+// Formats are the supported output formats
+var Formats = []string{
+	string(JSON),
+	string(Table),
+	string(CSV),
+	string(Markdown),
+}
+
+// ParseFormat is a function that parses an output format
+// The empty string is JSON
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return JSON, nil
+	}
+	if !slices.Contains(Formats, s) {
+		return "", fmt.Errorf("unsupported output format %q (expected one of: %s)", s, strings.Join(Formats, ", "))
+	}
+	return Format(s), nil
+}
+
+// Row is a type that represents a row of results
+// Labels identify the row (e.g. a series' labels) and Values correspond to the Result's Columns
+type Row struct {
+	Labels map[string]string
+	Values []string
+}
+
+// Result is a type that represents tabular results
+// Labels that are common to all rows are factored out of the rows
+type Result struct {
+	// Common are the labels common to all rows
+	Common map[string]string
+	// Labels are the names of labels that vary between rows
+	Labels []string
+	// Columns are the names of the (non-label) values of each row
+	Columns []string
+	Rows    []Row
+}
+
+// NewResult is a function that creates a Result from rows factoring out common labels
+func NewResult(columns []string, rows []Row) *Result {
+	common := map[string]string{}
+	names := []string{}
+
+	// Labels are common if these are present (with the same value) in every row
+	if len(rows) != 0 {
+		for name, value := range rows[0].Labels {
+			common[name] = value
+		}
+	}
+	for _, row := range rows {
+		for name, value := range row.Labels {
+			if v, ok := common[name]; !ok || v != value {
+				delete(common, name)
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		for name := range common {
+			if _, ok := row.Labels[name]; !ok {
+				delete(common, name)
+			}
+		}
+	}
+
+	labels := []string{}
+	for _, name := range names {
+		if _, ok := common[name]; !ok {
+			labels = append(labels, name)
+		}
+	}
+
+	// Rows must have at least one column so, if there are no other columns, don't factor out common labels
+	if len(labels) == 0 && len(columns) == 0 {
+		labels = names
+		common = map[string]string{}
+	}
+	sortLabels(labels)
+
+	return &Result{
+		Common:  common,
+		Labels:  labels,
+		Columns: columns,
+		Rows:    rows,
+	}
+}
+
+// Render is a method that renders the Result in a format
+func (r *Result) Render(format Format) (string, error) {
+	if len(r.Rows) == 0 && format != CSV {
+		return "no results\n", nil
+	}
+
+	switch format {
+	case Table:
+		return r.table(), nil
+	case CSV:
+		return r.csv()
+	case Markdown:
+		return r.markdown(), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// header is a method that returns the Result's column headers
+func (r *Result) header() []string {
+	return append(slices.Clone(r.Labels), r.Columns...)
+}
+
+// records is a method that returns the Result's rows as records (corresponding to the header)
+func (r *Result) records() [][]string {
+	records := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		record := make([]string, 0, len(r.Labels)+len(r.Columns))
+		for _, name := range r.Labels {
+			record = append(record, row.Labels[name])
+		}
+		records[i] = append(record, row.Values...)
+	}
+	return records
+}
+
+// common is a method that formats the common labels as a label set e.g. {job="prometheus"}
+// It returns "" if there are no common labels
+func (r *Result) common() string {
+	if len(r.Common) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(r.Common))
+	for name := range r.Common {
+		names = append(names, name)
+	}
+	sortLabels(names)
+
+	ss := make([]string, len(names))
+	for i, name := range names {
+		ss[i] = fmt.Sprintf("%s=%q", name, r.Common[name])
+	}
+	return "{" + strings.Join(ss, ", ") + "}"
+}
+
+// table is a method that renders the Result as an aligned text table
+func (r *Result) table() string {
+	var b bytes.Buffer
+	if common := r.common(); common != "" {
+		fmt.Fprintf(&b, "common: %s\n", common)
+	}
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(r.header(), "\t"))
+	for _, record := range r.records() {
+		fmt.Fprintln(w, strings.Join(record, "\t"))
+	}
+	w.Flush()
+
+	return b.String()
+}
+
+// csv is a method that renders the Result as CSV
+// Common labels are included as a comment preceding the header
+func (r *Result) csv() (string, error) {
+	var b bytes.Buffer
+	if common := r.common(); common != "" {
+		fmt.Fprintf(&b, "# common: %s\n", common)
+	}
+
+	w := csv.NewWriter(&b)
+	if err := w.Write(r.header()); err != nil {
+		return "", err
+	}
+	if err := w.WriteAll(r.records()); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// markdown is a method that renders the Result as a Markdown table
+func (r *Result) markdown() string {
+	escape := func(ss []string) []string {
+		result := make([]string, len(ss))
+		for i, s := range ss {
+			result[i] = strings.ReplaceAll(s, "|", `\|`)
+		}
+		return result
+	}
+
+	var b strings.Builder
+	if common := r.common(); common != "" {
+		fmt.Fprintf(&b, "Common labels: `%s`\n\n", common)
+	}
+
+	header := r.header()
+	fmt.Fprintf(&b, "| %s |\n", strings.Join(escape(header), " | "))
+	fmt.Fprintf(&b, "|%s\n", strings.Repeat("---|", len(header)))
+	for _, record := range r.records() {
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(escape(record), " | "))
+	}
+
+	return b.String()
+}
+
+// Value is a function that converts a Prometheus query result into a Result
+// Vectors have a row per series, matrices have a row per sample
+func Value(value model.Value) *Result {
+	switch v := value.(type) {
+	case model.Vector:
+		rows := make([]Row, len(v))
+		for i, sample := range v {
+			rows[i] = Row{
+				Labels: labels(sample.Metric),
+				Values: []string{
+					FormatTime(sample.Timestamp.Time()),
+					FormatValue(float64(sample.Value)),
+				},
+			}
+		}
+		return NewResult([]string{"timestamp", "value"}, rows)
+	case model.Matrix:
+		rows := []Row{}
+		for _, stream := range v {
+			l := labels(stream.Metric)
+			for _, pair := range stream.Values {
+				rows = append(rows, Row{
+					Labels: l,
+					Values: []string{
+						FormatTime(pair.Timestamp.Time()),
+						FormatValue(float64(pair.Value)),
+					},
+				})
+			}
+			// Native histograms are summarized by their count and sum
+			for _, pair := range stream.Histograms {
+				rows = append(rows, Row{
+					Labels: l,
+					Values: []string{
+						FormatTime(pair.Timestamp.Time()),
+						fmt.Sprintf("count:%s sum:%s",
+							FormatValue(float64(pair.Histogram.Count)),
+							FormatValue(float64(pair.Histogram.Sum)),
+						),
+					},
+				})
+			}
+		}
+		return NewResult([]string{"timestamp", "value"}, rows)
+	case *model.Scalar:
+		return NewResult([]string{"timestamp", "value"}, []Row{
+			{
+				Values: []string{
+					FormatTime(v.Timestamp.Time()),
+					FormatValue(float64(v.Value)),
+				},
+			},
+		})
+	case *model.String:
+		return NewResult([]string{"timestamp", "value"}, []Row{
+			{
+				Values: []string{
+					FormatTime(v.Timestamp.Time()),
+					v.Value,
+				},
+			},
+		})
+	default:
+		return NewResult(nil, nil)
+	}
+}
+
+// Series is a function that converts a list of series into a Result
+func Series(series []model.LabelSet) *Result {
+	rows := make([]Row, len(series))
+	for i, s := range series {
+		rows[i] = Row{
+			Labels: labels(model.Metric(s)),
+		}
+	}
+	return NewResult(nil, rows)
+}
+
+// Targets is a function that converts (active) targets into a Result
+func Targets(targets v1.TargetsResult) *Result {
+	rows := make([]Row, len(targets.Active))
+	for i, target := range targets.Active {
+		rows[i] = Row{
+			Labels: labels(model.Metric(target.Labels)),
+			Values: []string{
+				string(target.Health),
+				FormatTime(target.LastScrape),
+				FormatValue(target.LastScrapeDuration),
+				target.LastError,
+			},
+		}
+	}
+	return NewResult([]string{"health", "last_scrape", "scrape_duration", "error"}, rows)
+}
+
+// Alerts is a function that converts alerts into a Result
+func Alerts(alerts v1.AlertsResult) *Result {
+	rows := make([]Row, len(alerts.Alerts))
+	for i, alert := range alerts.Alerts {
+		rows[i] = Row{
+			Labels: labels(model.Metric(alert.Labels)),
+			Values: []string{
+				string(alert.State),
+				FormatTime(alert.ActiveAt),
+				alert.Value,
+				string(alert.Annotations["summary"]),
+			},
+		}
+	}
+	return NewResult([]string{"state", "active_at", "value", "summary"}, rows)
+}
+
+// FormatTime is a function that formats a timestamp compactly
+// Timestamps are UTC and only include fractional seconds if non-zero
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// FormatValue is a function that formats a sample value compactly
+// Values are formatted like Prometheus' exposition format: the shortest representation and, for large and small values, an exponent
+func FormatValue(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// labels is a function that converts a metric into a map of labels
+func labels(metric model.Metric) map[string]string {
+	result := make(map[string]string, len(metric))
+	for name, value := range metric {
+		result[string(name)] = string(value)
+	}
+	return result
+}
+
+// sortLabels is a function that sorts label names with the metric name first
+func sortLabels(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == model.MetricNameLabel || names[j] == model.MetricNameLabel {
+			return names[i] == model.MetricNameLabel && names[j] != model.MetricNameLabel
+		}
+		return names[i] < names[j]
+	})
+}
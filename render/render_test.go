@@ -0,0 +1,129 @@
+package render
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+var (
+	timestamp = model.TimeFromUnix(time.Date(2025, time.June, 13, 10, 0, 0, 0, time.UTC).Unix())
+	vector    = model.Vector{
+		{
+			Metric:    model.Metric{"__name__": "up", "job": "prometheus", "instance": "localhost:9090"},
+			Value:     1,
+			Timestamp: timestamp,
+		},
+		{
+			Metric:    model.Metric{"__name__": "up", "job": "prometheus", "instance": "localhost:9091"},
+			Value:     0,
+			Timestamp: timestamp,
+		},
+	}
+)
+
+// TestParseFormat tests ParseFormat
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"", "json", "table", "csv", "markdown"} {
+		if _, err := ParseFormat(s); err != nil {
+			t.Errorf("%q: unexpected error: %+v", s, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestRender tests rendering a vector in each format
+func TestRender(t *testing.T) {
+	r := Value(vector)
+
+	tests := map[Format]string{
+		Table: `common: {__name__="up", job="prometheus"}
+instance        timestamp             value
+localhost:9090  2025-06-13T10:00:00Z  1
+localhost:9091  2025-06-13T10:00:00Z  0
+`,
+		CSV: `# common: {__name__="up", job="prometheus"}
+instance,timestamp,value
+localhost:9090,2025-06-13T10:00:00Z,1
+localhost:9091,2025-06-13T10:00:00Z,0
+`,
+		Markdown: "Common labels: `{__name__=\"up\", job=\"prometheus\"}`" + `
+
+| instance | timestamp | value |
+|---|---|---|
+| localhost:9090 | 2025-06-13T10:00:00Z | 1 |
+| localhost:9091 | 2025-06-13T10:00:00Z | 0 |
+`,
+	}
+	for format, want := range tests {
+		got, err := r.Render(format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %+v", format, err)
+		}
+		if got != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", format, got, want)
+		}
+	}
+}
+
+// TestValueMatrix tests that matrices have a row per sample
+func TestValueMatrix(t *testing.T) {
+	matrix := model.Matrix{
+		{
+			Metric: model.Metric{"job": "prometheus"},
+			Values: []model.SamplePair{
+				{Timestamp: timestamp, Value: 1.5},
+				{Timestamp: timestamp.Add(time.Minute), Value: 2},
+			},
+		},
+	}
+
+	r := Value(matrix)
+	if len(r.Rows) != 2 {
+		t.Fatalf("got: %d rows; want: 2", len(r.Rows))
+	}
+	if r.Common["job"] != "prometheus" {
+		t.Errorf("expected common label")
+	}
+	if got := r.Rows[1].Values; got[0] != "2025-06-13T10:01:00Z" || got[1] != "2" {
+		t.Errorf("got: %+q", got)
+	}
+}
+
+// TestSeries tests that a single series isn't factored away entirely
+func TestSeries(t *testing.T) {
+	r := Series([]model.LabelSet{
+		{"__name__": "up", "job": "prometheus"},
+	})
+	if len(r.Common) != 0 {
+		t.Errorf("expected no common labels")
+	}
+	if len(r.Labels) != 2 || r.Labels[0] != "__name__" {
+		t.Errorf("got: %+q", r.Labels)
+	}
+}
+
+// TestFormatValue tests FormatValue
+func TestFormatValue(t *testing.T) {
+	tests := map[float64]string{
+		1:          "1",
+		0.25:       "0.25",
+		123456:     "123456",
+		1749808800: "1.7498088e+09",
+		// Very large and very small values have exponents rather than hundreds of digits
+		1.7e308:                     "1.7e+308",
+		1e-300:                      "1e-300",
+		-2.5e-7:                     "-2.5e-07",
+		math.MaxFloat64:             "1.7976931348623157e+308",
+		math.SmallestNonzeroFloat64: "5e-324",
+	}
+	for f, want := range tests {
+		if got := FormatValue(f); got != want {
+			t.Errorf("got: %s; want: %s", got, want)
+		}
+	}
+}
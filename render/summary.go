@@ -0,0 +1,245 @@
+package render
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// sparklineWidth is the maximum number of characters in a sparkline
+	sparklineWidth int = 20
+)
+
+// sparks are the characters used by sparklines from lowest to highest
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Summary is a type that represents the statistics of a series' samples
+// Values are model.SampleValue so that NaN and ±Inf are marshaled (as strings) like Prometheus' values
+type Summary struct {
+	Metric model.Metric `json:"metric"`
+	// Count is the number of samples
+	Count int `json:"count"`
+	// NaN is the number of samples that are NaN
+	NaN int `json:"nan"`
+	// Inf is the number of samples that are ±Inf
+	Inf int `json:"inf"`
+	// Gaps is the number of steps in the range that have no sample
+	Gaps int `json:"gaps"`
+	// Statistics exclude NaN and ±Inf samples and are omitted if there are none
+	Min   *model.SampleValue `json:"min,omitempty"`
+	Max   *model.SampleValue `json:"max,omitempty"`
+	Avg   *model.SampleValue `json:"avg,omitempty"`
+	P50   *model.SampleValue `json:"p50,omitempty"`
+	P95   *model.SampleValue `json:"p95,omitempty"`
+	First *model.SampleValue `json:"first,omitempty"`
+	Last  *model.SampleValue `json:"last,omitempty"`
+	// Slope is the (least squares) rate of change per second
+	Slope *model.SampleValue `json:"slope,omitempty"`
+	// Sparkline is a sketch of the samples over the range; gaps are spaces
+	Sparkline string `json:"sparkline"`
+}
+
+// Summarize is a function that summarizes each series of a range query's result
+// The range (start, end, step) is used to identify gaps and to align sparklines
+func Summarize(matrix model.Matrix, start, end time.Time, step time.Duration) []Summary {
+	summaries := make([]Summary, len(matrix))
+	for i, stream := range matrix {
+		summaries[i] = summarize(stream, start, end, step)
+	}
+	return summaries
+}
+
+// summarize is a function that summarizes a series
+func summarize(stream *model.SampleStream, start, end time.Time, step time.Duration) Summary {
+	s := Summary{
+		Metric: stream.Metric,
+		Count:  len(stream.Values),
+	}
+
+	// Gaps
+	steps := 1
+	if step > 0 && end.After(start) {
+		steps = int(end.Sub(start)/step) + 1
+	}
+	s.Gaps = max(0, steps-len(stream.Values))
+
+	// Finite (neither NaN nor ±Inf) values and their timestamps
+	values := []float64{}
+	times := []float64{}
+	for _, pair := range stream.Values {
+		f := float64(pair.Value)
+		if math.IsNaN(f) {
+			s.NaN++
+			continue
+		}
+		if math.IsInf(f, 0) {
+			s.Inf++
+			continue
+		}
+		values = append(values, f)
+		times = append(times, float64(pair.Timestamp.Time().Sub(start))/float64(time.Second))
+	}
+
+	s.Sparkline = sparkline(stream.Values, start, end, step)
+
+	if len(values) == 0 {
+		return s
+	}
+
+	sum := 0.0
+	for _, f := range values {
+		sum += f
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	s.Min = sampleValue(sorted[0])
+	s.Max = sampleValue(sorted[len(sorted)-1])
+	s.Avg = sampleValue(sum / float64(len(values)))
+	s.P50 = sampleValue(quantile(sorted, 0.5))
+	s.P95 = sampleValue(quantile(sorted, 0.95))
+	s.First = sampleValue(values[0])
+	s.Last = sampleValue(values[len(values)-1])
+	s.Slope = sampleValue(slope(times, values))
+
+	return s
+}
+
+// sampleValue is a function that returns a pointer to a float64 as a model.SampleValue
+func sampleValue(f float64) *model.SampleValue {
+	v := model.SampleValue(f)
+	return &v
+}
+
+// quantile is a function that calculates the q-quantile of sorted values using linear interpolation
+// This is consistent with PromQL's quantile_over_time
+func quantile(sorted []float64, q float64) float64 {
+	n := float64(len(sorted))
+	rank := q * (n - 1)
+
+	lower := math.Max(0, math.Floor(rank))
+	upper := math.Min(n-1, lower+1)
+	weight := rank - math.Floor(rank)
+
+	return sorted[int(lower)]*(1-weight) + sorted[int(upper)]*weight
+}
+
+// slope is a function that calculates the (least squares) slope of values against times (seconds)
+// This is consistent with PromQL's deriv
+func slope(times, values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumX2 float64
+	for i := range values {
+		sumX += times[i]
+		sumY += values[i]
+		sumXY += times[i] * values[i]
+		sumX2 += times[i] * times[i]
+	}
+
+	denominator := n*sumX2 - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// sparkline is a function that sketches samples over a range
+// Samples are bucketed (by timestamp) into at most sparklineWidth characters using the mean of each bucket
+// Buckets without (finite) samples are spaces
+func sparkline(pairs []model.SamplePair, start, end time.Time, step time.Duration) string {
+	steps := 1
+	if step > 0 && end.After(start) {
+		steps = int(end.Sub(start)/step) + 1
+	}
+	width := min(steps, sparklineWidth)
+
+	sums := make([]float64, width)
+	counts := make([]int, width)
+	for _, pair := range pairs {
+		f := float64(pair.Value)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+
+		// Position of the sample's step within the range
+		i := 0
+		if step > 0 {
+			i = int(pair.Timestamp.Time().Sub(start) / step)
+		}
+		bucket := min(width-1, max(0, i*width/steps))
+
+		sums[bucket] += f
+		counts[bucket]++
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i := range sums {
+		if counts[i] == 0 {
+			continue
+		}
+		sums[i] /= float64(counts[i])
+		lo = math.Min(lo, sums[i])
+		hi = math.Max(hi, sums[i])
+	}
+
+	var b strings.Builder
+	for i := range sums {
+		if counts[i] == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+
+		// Flat series are drawn mid-height
+		level := len(sparks) / 2
+		if hi > lo {
+			level = int((sums[i] - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[level])
+	}
+
+	return b.String()
+}
+
+// Summaries is a function that converts summaries into a Result
+func Summaries(summaries []Summary) *Result {
+	format := func(v *model.SampleValue) string {
+		if v == nil {
+			return ""
+		}
+		return FormatValue(float64(*v))
+	}
+
+	rows := make([]Row, len(summaries))
+	for i, s := range summaries {
+		rows[i] = Row{
+			Labels: labels(s.Metric),
+			Values: []string{
+				strconv.Itoa(s.Count),
+				strconv.Itoa(s.NaN),
+				strconv.Itoa(s.Inf),
+				strconv.Itoa(s.Gaps),
+				format(s.Min),
+				format(s.Max),
+				format(s.Avg),
+				format(s.P50),
+				format(s.P95),
+				format(s.First),
+				format(s.Last),
+				format(s.Slope),
+				s.Sparkline,
+			},
+		}
+	}
+
+	return NewResult([]string{"count", "nan", "inf", "gaps", "min", "max", "avg", "p50", "p95", "first", "last", "slope", "sparkline"}, rows)
+}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// TestSummarize tests Summarize
+func TestSummarize(t *testing.T) {
+	start := timestamp.Time()
+	step := time.Minute
+	end := start.Add(9 * step)
+
+	// 10 steps: 8 samples (one NaN) and 2 gaps
+	values := []model.SamplePair{}
+	for i, f := range []float64{1, 2, 3, 4, math.NaN(), 6, 7, 8} {
+		values = append(values, model.SamplePair{
+			Timestamp: timestamp.Add(time.Duration(i) * step),
+			Value:     model.SampleValue(f),
+		})
+	}
+
+	matrix := model.Matrix{
+		{
+			Metric: model.Metric{"job": "prometheus"},
+			Values: values,
+		},
+	}
+
+	summaries := Summarize(matrix, start, end, step)
+	if len(summaries) != 1 {
+		t.Fatalf("got: %d summaries; want: 1", len(summaries))
+	}
+	s := summaries[0]
+
+	if s.Count != 8 || s.NaN != 1 || s.Gaps != 2 {
+		t.Errorf("got: count=%d nan=%d gaps=%d; want: count=8 nan=1 gaps=2", s.Count, s.NaN, s.Gaps)
+	}
+
+	tests := map[string]struct {
+		got  *model.SampleValue
+		want float64
+	}{
+		"min":   {s.Min, 1},
+		"max":   {s.Max, 8},
+		"avg":   {s.Avg, 31.0 / 7.0},
+		"p50":   {s.P50, 4},
+		"first": {s.First, 1},
+		"last":  {s.Last, 8},
+		// 1 per minute
+		"slope": {s.Slope, 1.0 / 60.0},
+	}
+	for name, test := range tests {
+		if test.got == nil {
+			t.Errorf("%s: expected value", name)
+			continue
+		}
+		if got := float64(*test.got); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got: %v; want: %v", name, got, test.want)
+		}
+	}
+
+	// Sparkline has a character per step; the NaN and the gaps are spaces
+	want := "▁▂▃▄ ▆▇█  "
+	if s.Sparkline != want {
+		t.Errorf("sparkline: got: %q; want: %q", s.Sparkline, want)
+	}
+
+	// Summaries (including NaN) must be marshalable
+	if _, err := json.Marshal(summaries); err != nil {
+		t.Errorf("unable to marshal summaries: %+v", err)
+	}
+}
+
+// TestSummarizeNaN tests that series without (finite) values omit statistics
+func TestSummarizeNaN(t *testing.T) {
+	start := timestamp.Time()
+	matrix := model.Matrix{
+		{
+			Metric: model.Metric{"job": "prometheus"},
+			Values: []model.SamplePair{
+				{Timestamp: timestamp, Value: model.SampleValue(math.NaN())},
+			},
+		},
+	}
+
+	summaries := Summarize(matrix, start, start, time.Minute)
+	b, err := json.Marshal(summaries)
+	if err != nil {
+		t.Fatalf("unable to marshal summaries: %+v", err)
+	}
+	if strings.Contains(string(b), `"min"`) {
+		t.Errorf("expected no statistics: %s", b)
+	}
+}
+
+// TestSummarizeInf tests that ±Inf values are counted and excluded from statistics
+func TestSummarizeInf(t *testing.T) {
+	start := timestamp.Time()
+	step := time.Minute
+	end := start.Add(3 * step)
+
+	values := []model.SamplePair{}
+	for i, f := range []float64{1, math.Inf(1), 3, math.Inf(-1)} {
+		values = append(values, model.SamplePair{
+			Timestamp: timestamp.Add(time.Duration(i) * step),
+			Value:     model.SampleValue(f),
+		})
+	}
+
+	matrix := model.Matrix{
+		{
+			Metric: model.Metric{"job": "prometheus"},
+			Values: values,
+		},
+	}
+
+	s := Summarize(matrix, start, end, step)[0]
+	if s.Count != 4 || s.NaN != 0 || s.Inf != 2 {
+		t.Errorf("got: count=%d nan=%d inf=%d; want: count=4 nan=0 inf=2", s.Count, s.NaN, s.Inf)
+	}
+
+	tests := map[string]struct {
+		got  *model.SampleValue
+		want float64
+	}{
+		"min":  {s.Min, 1},
+		"max":  {s.Max, 3},
+		"avg":  {s.Avg, 2},
+		"last": {s.Last, 3},
+		// 2 per 2 minutes
+		"slope": {s.Slope, 1.0 / 60.0},
+	}
+	for name, test := range tests {
+		if test.got == nil {
+			t.Errorf("%s: expected value", name)
+			continue
+		}
+		if got := float64(*test.got); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got: %v; want: %v", name, got, test.want)
+		}
+	}
+}
+
+// TestSparklineWidth tests that long ranges are bucketed
+func TestSparklineWidth(t *testing.T) {
+	start := timestamp.Time()
+	step := time.Minute
+
+	values := []model.SamplePair{}
+	for i := range 100 {
+		values = append(values, model.SamplePair{
+			Timestamp: timestamp.Add(time.Duration(i) * step),
+			Value:     model.SampleValue(i),
+		})
+	}
+
+	got := sparkline(values, start, start.Add(99*step), step)
+	if n := len([]rune(got)); n != sparklineWidth {
+		t.Errorf("got: %d characters; want: %d", n, sparklineWidth)
+	}
+	if !strings.HasPrefix(got, "▁") || !strings.HasSuffix(got, "█") {
+		t.Errorf("got: %q", got)
+	}
+}
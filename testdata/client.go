@@ -26,10 +26,39 @@ var (
 	AlertsResult = v1.AlertsResult{
 		Alerts: Alerts,
 	}
+	LabelNames = []string{
+		"__name__",
+		"instance",
+		"job",
+	}
 	LabelValues = model.LabelValues{
 		model.LabelValue("foo"),
 		model.LabelValue("bar"),
 	}
+	Metadata = map[string][]v1.Metadata{
+		"up": {
+			{
+				Type: v1.MetricTypeGauge,
+				Help: "1 if the target is up, 0 otherwise",
+				Unit: "",
+			},
+		},
+	}
+	// Abbreviated Prometheus configuration as returned by /api/v1/status/config
+	ConfigResult = v1.ConfigResult{
+		YAML: `global:
+  scrape_interval: 15s
+  scrape_timeout: 10s
+  evaluation_interval: 15s
+rule_files:
+- /etc/prometheus/rules.yml
+scrape_configs:
+- job_name: prometheus
+  static_configs:
+  - targets:
+    - localhost:9090
+`,
+	}
 	Duration    = time.Duration(5 * time.Second)
 	ModelVector = model.Vector{
 		{
@@ -43,13 +72,29 @@ var (
 			Value:     model.SampleValue(1),
 		},
 	}
+	Series = []model.LabelSet{
+		{
+			"__name__": "up",
+			"instance": "localhost:9090",
+			"job":      "prometheus",
+		},
+		{
+			"__name__": "up",
+			"instance": "localhost:9100",
+			"job":      "node",
+		},
+	}
 	Time      = Timestamp.Format(time.RFC3339)
 	Timestamp = time.Date(2025, time.June, 13, 0, 0, 0, 0, time.UTC)
 )
 var (
 	JsonAlertsResult []byte = MustMarshal(AlertsResult)
+	JsonConfigResult []byte = MustMarshal(ConfigResult)
+	JsonLabelNames   []byte = MustMarshal(LabelNames)
 	JsonLabelValues  []byte = MustMarshal(LabelValues)
+	JsonMetadata     []byte = MustMarshal(Metadata)
 	JsonModelVector  []byte = MustMarshal(ModelVector)
+	JsonSeries       []byte = MustMarshal(Series)
 )
 
 // ModelValue implements Prometheus' model.Value interface
@@ -43,6 +43,10 @@ var (
 	// 	},
 	// }
 	MetaToolsTests = ToolsTests{
+		"healthy": {
+			// No additional params
+			"": {},
+		},
 		"ping": {
 			// No additional params
 			"": {},
@@ -56,6 +60,9 @@ var (
 		"alerts": {
 			// No additional params
 			"": {},
+			"+output": {
+				"output": "markdown",
+			},
 		},
 		"exemplars": {
 			"required": {
@@ -64,6 +71,57 @@ var (
 				"end":   end,
 			},
 		},
+		"format_query": {
+			"required": {
+				"query": `sum by (job) (rate(prometheus_http_requests_total{job="prometheus",code=~"5.."}[5m])) / on (job) sum by (job) (rate(prometheus_http_requests_total{job="prometheus"}[5m]))`,
+			},
+		},
+		"label_values": {
+			"required": {
+				"label": "job",
+			},
+			"+match[]": {
+				"label": "job",
+				"match[]": []string{
+					query,
+				},
+			},
+			"+start+end+limit": {
+				"label": "job",
+				"start": start,
+				"end":   end,
+				"limit": limit,
+			},
+		},
+		"labels": {
+			// No additional params
+			"": {},
+			"+match[]": {
+				"match[]": []string{
+					query,
+				},
+			},
+			"+start+end+limit": {
+				"start": start,
+				"end":   end,
+				"limit": limit,
+			},
+		},
+		"lint_query": {
+			"required": {
+				"query": `sum(rate(prometheus_http_requests_total{job="prometheus"}[5s]))`,
+			},
+		},
+		"metadata": {
+			// No additional params
+			"": {},
+			"+metric": {
+				"metric": "up",
+			},
+			"+limit": {
+				"limit": limit,
+			},
+		},
 		"metrics": {
 			// No additional params
 			"": {},
@@ -87,14 +145,28 @@ var (
 				"timeout": timeout,
 				"limit":   limit,
 			},
+			"+output": {
+				"query":  query,
+				"output": "table",
+			},
 		},
 		"query_range": {
 			"required": {
 				"query": query,
 				"start": start,
 				"end":   end,
+			},
+			"+step": {
+				"query": query,
+				"start": start,
+				"end":   end,
 				"step":  step,
 			},
+			"+relative": {
+				"query": query,
+				"start": "1h",
+				"end":   "now",
+			},
 			"+limit": {
 				"query": query,
 				"start": start,
@@ -102,6 +174,18 @@ var (
 				"step":  step,
 				"limit": limit,
 			},
+			"+output": {
+				"query":  query,
+				"start":  start,
+				"end":    end,
+				"output": "csv",
+			},
+			"+summary": {
+				"query":   query,
+				"start":   start,
+				"end":     end,
+				"summary": true,
+			},
 		},
 		"rules": {
 			// No additional params
@@ -124,14 +208,70 @@ var (
 				"end":   end,
 				"limit": limit,
 			},
+			"+output": {
+				"match[]": []string{
+					query,
+				},
+				"start":  start,
+				"end":    end,
+				"output": "table",
+			},
+		},
+		"status_buildinfo": {
+			// No additional params
+			"": {},
+		},
+		"status_config": {
+			// No additional params
+			"": {},
+			"+sections": {
+				"sections": []string{
+					"global",
+					"scrape_configs",
+					"rule_files",
+					"remote_write",
+				},
+			},
+		},
+		"status_flags": {
+			// No additional params
+			"": {},
+		},
+		"status_runtimeinfo": {
+			// No additional params
+			"": {},
 		},
 		"status_tsdb": {
 			// No additional params
 			"": {},
 		},
+		"status_walreplay": {
+			// No additional params
+			"": {},
+		},
 		"targets": {
 			// No additional params
 			"": {},
+			"+output": {
+				"output": "table",
+			},
+		},
+		"targets_metadata": {
+			// No additional params
+			"": {},
+			"+match_target+metric+limit": {
+				"match_target": `{job="prometheus"}`,
+				"metric":       "up",
+				"limit":        limit,
+			},
+		},
+		"validate_query": {
+			"required": {
+				"query": query,
+			},
+			"invalid": {
+				"query": "sum(rate(up[5m])",
+			},
 		},
 	}
 )
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+)
+
+// tenantKey is a type that represents the context key of the tenant
+type tenantKey struct{}
+
+// WithTenant is a function that returns a copy of ctx with the tenant of requests
+// The tenant overrides the configured default tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom is a function that returns the tenant of requests (if any) of ctx (see WithTenant)
+func TenantFrom(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(tenantKey{}).(string)
+	return v, ok && v != ""
+}
+
+// tenant is a type that represents an http.RoundTripper that identifies the tenant of requests
+// The tenant is the request context's tenant (see WithTenant) or the default tenant
+type tenant struct {
+	header string
+	value  string
+	next   http.RoundTripper
+}
+
+// RoundTrip is a method that implements http.RoundTripper
+func (rt *tenant) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	value := rt.value
+	if v, ok := TenantFrom(rqst.Context()); ok {
+		value = v
+	}
+	if value == "" {
+		return rt.next.RoundTrip(rqst)
+	}
+
+	rqst = rqst.Clone(rqst.Context())
+	rqst.Header.Set(rt.header, value)
+	return rt.next.RoundTrip(rqst)
+}
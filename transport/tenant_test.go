@@ -0,0 +1,54 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// TestTenant tests that requests identify the default tenant or the request context's tenant
+func TestTenant(t *testing.T) {
+	server, got := newServer(t)
+
+	tests := []struct {
+		name   string
+		tenant config.Tenant
+		ctx    string
+		header string
+		want   string
+	}{
+		{name: "disabled", tenant: config.Tenant{}, header: config.DefaultTenantHeader, want: ""},
+		{name: "default", tenant: config.Tenant{Default: "team-a"}, header: config.DefaultTenantHeader, want: "team-a"},
+		{name: "context", tenant: config.Tenant{Default: "team-a", Allowed: config.Tenants{"team-b"}}, ctx: "team-b", header: config.DefaultTenantHeader, want: "team-b"},
+		{name: "no default", tenant: config.Tenant{Allowed: config.Tenants{"team-b"}}, header: config.DefaultTenantHeader, want: ""},
+		{name: "header", tenant: config.Tenant{Header: "thanos-tenant", Default: "team-a"}, header: "Thanos-Tenant", want: "team-a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt, err := New(config.HTTPClient{Tenant: test.tenant})
+			if err != nil {
+				t.Fatalf("unable to create RoundTripper: %+v", err)
+			}
+
+			ctx := context.Background()
+			if test.ctx != "" {
+				ctx = WithTenant(ctx, test.ctx)
+			}
+			rqst, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatalf("unable to create request: %+v", err)
+			}
+			resp, err := rt.RoundTrip(rqst)
+			if err != nil {
+				t.Fatalf("unable to GET %q: %+v", server.URL, err)
+			}
+			resp.Body.Close()
+
+			if v := got.Get(test.header); v != test.want {
+				t.Errorf("got: %q; want: %q", v, test.want)
+			}
+		})
+	}
+}
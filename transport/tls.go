@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// newTLS is a function that creates the (base) http.RoundTripper that establishes TLS connections
+// The client certificate and the CA certificates are reread when these change
+func newTLS(c config.TLS) (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	// The client certificate is (re)loaded when the server requests it i.e. for each new connection
+	if c.CertFile != "" {
+		pair := &keyPair{
+			cert: &file{path: c.CertFile},
+			key:  &file{path: c.KeyFile},
+		}
+		// Fail fast if the certificate can't be loaded
+		if _, err := pair.Get(); err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return pair.Get()
+		}
+	}
+
+	if c.CAFile == "" {
+		return base, nil
+	}
+
+	rt := &caTransport{
+		ca:   &file{path: c.CAFile},
+		base: base,
+	}
+	// Fail fast if the CA certificates can't be loaded
+	if _, err := rt.current(); err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+// caTransport is a type that represents an http.RoundTripper that verifies servers using CA certificates from a file
+// When the file changes, subsequent requests use a new http.Transport (and connections) with the new CA certificates
+type caTransport struct {
+	ca   *file
+	base *http.Transport
+
+	mu        sync.Mutex
+	pem       string
+	transport *http.Transport
+}
+
+// RoundTrip is a method that implements http.RoundTripper
+func (rt *caTransport) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	transport, err := rt.current()
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(rqst)
+}
+
+// current is a method that returns the http.Transport for the current CA certificates
+func (rt *caTransport) current() (*http.Transport, error) {
+	pem, err := rt.ca.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.transport != nil && pem == rt.pem {
+		return rt.transport, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(pem)) {
+		return nil, fmt.Errorf("unable to parse CA certificates from %q", rt.ca.path)
+	}
+
+	transport := rt.base.Clone()
+	transport.TLSClientConfig.RootCAs = pool
+
+	// Connections verified using the previous CA certificates are no longer used
+	if rt.transport != nil {
+		rt.transport.CloseIdleConnections()
+	}
+	rt.transport, rt.pem = transport, pem
+
+	return transport, nil
+}
+
+// keyPair is a type that represents a client certificate and key that are reloaded when either file changes
+type keyPair struct {
+	cert *file
+	key  *file
+
+	mu      sync.Mutex
+	certPEM string
+	keyPEM  string
+	pair    *tls.Certificate
+}
+
+// Get is a method that returns the client certificate
+func (k *keyPair) Get() (*tls.Certificate, error) {
+	certPEM, err := k.cert.Read()
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := k.key.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.pair != nil && certPEM == k.certPEM && keyPEM == k.keyPEM {
+		return k.pair, nil
+	}
+
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load client certificate %q and key %q: %w", k.cert.path, k.key.path, err)
+	}
+	k.certPEM, k.keyPEM, k.pair = certPEM, keyPEM, &pair
+
+	return k.pair, nil
+}
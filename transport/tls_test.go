@@ -0,0 +1,223 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// certificate is a type that represents a (PEM-encoded) certificate and key
+type certificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	PEM  string
+	Key  string
+}
+
+// newCertificate is a function that creates a certificate signed by parent (or self-signed if parent is nil)
+func newCertificate(t *testing.T, name string, parent *certificate) *certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %+v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %+v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %+v", err)
+	}
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshal key: %+v", err)
+	}
+
+	return &certificate{
+		cert: cert,
+		key:  key,
+		PEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Key:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})),
+	}
+}
+
+// serverCA is a function that PEM-encodes the certificate of an httptest TLS server
+func serverCA(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+// TestTLS tests that servers are verified using the CA certificates, server name or not at all
+func TestTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	write(t, ca, serverCA(server), time.Now())
+
+	tests := []struct {
+		name string
+		tls  config.TLS
+		ok   bool
+	}{
+		{name: "system CAs", tls: config.TLS{}, ok: false},
+		{name: "CA file", tls: config.TLS{CAFile: ca}, ok: true},
+		// httptest's certificate is valid for example.com (and *.example.com)
+		{name: "server name", tls: config.TLS{CAFile: ca, ServerName: "example.com"}, ok: true},
+		{name: "wrong server name", tls: config.TLS{CAFile: ca, ServerName: "prometheus.invalid"}, ok: false},
+		{name: "insecure skip verify", tls: config.TLS{InsecureSkipVerify: true}, ok: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt, err := New(config.HTTPClient{TLS: test.tls})
+			if err != nil {
+				t.Fatalf("unable to create RoundTripper: %+v", err)
+			}
+
+			client := &http.Client{Transport: rt}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != test.ok {
+				t.Errorf("got: %v; want ok: %t", err, test.ok)
+			}
+		})
+	}
+}
+
+// TestTLSReloadCA tests that the CA certificates are reread when the file changes
+func TestTLSReloadCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Initially, the CA file contains an unrelated CA
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	now := time.Now()
+	write(t, ca, newCertificate(t, "other", nil).PEM, now)
+
+	rt, err := New(config.HTTPClient{TLS: config.TLS{CAFile: ca}})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+	client := &http.Client{Transport: rt}
+
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected error")
+	}
+
+	// Replace the CA
+	write(t, ca, serverCA(server), now.Add(time.Minute))
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	resp.Body.Close()
+}
+
+// TestMTLS tests that the client certificate is presented to servers that require it and is reread when it changes
+func TestMTLS(t *testing.T) {
+	clientCA := newCertificate(t, "client-ca", nil)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCA.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	now := time.Now()
+	write(t, ca, serverCA(server), now)
+
+	// Initially, the client certificate isn't signed by the client CA
+	other := newCertificate(t, "other", nil)
+	write(t, cert, other.PEM, now)
+	write(t, key, other.Key, now)
+
+	rt, err := New(config.HTTPClient{
+		TLS: config.TLS{
+			CAFile:   ca,
+			CertFile: cert,
+			KeyFile:  key,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+	client := &http.Client{Transport: rt}
+
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected error")
+	}
+
+	// Replace the client certificate with one signed by the client CA
+	signed := newCertificate(t, "prometheus-mcp-server", clientCA)
+	write(t, cert, signed.PEM, now.Add(time.Minute))
+	write(t, key, signed.Key, now.Add(time.Minute))
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected success: %+v", err)
+	}
+	resp.Body.Close()
+}
+
+// TestTLSMissingKey tests that New fails if the client certificate can't be loaded
+func TestTLSMissingKey(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	write(t, cert, newCertificate(t, "client", nil).PEM, time.Now())
+
+	_, err := New(config.HTTPClient{
+		TLS: config.TLS{
+			CertFile: cert,
+			KeyFile:  filepath.Join(dir, "missing.pem"),
+		},
+	})
+	if err == nil {
+		t.Errorf("expected error")
+	}
+}
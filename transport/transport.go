@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// New is a function that creates an http.RoundTripper for requests to a Prometheus server
+// Requests include the configured headers and tenant and are authenticated using basic auth or a bearer token
+// Connections use the configured TLS (and mTLS) settings
+// The same RoundTripper should be used by the Prometheus API client and the Management API client
+func New(c config.HTTPClient) (http.RoundTripper, error) {
+	rt, err := newTLS(c.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Headers) != 0 {
+		rt = &headers{
+			headers: c.Headers,
+			next:    rt,
+		}
+	}
+
+	if c.Tenant.Enabled() {
+		rt = &tenant{
+			header: c.Tenant.HeaderName(),
+			value:  c.Tenant.Default,
+			next:   rt,
+		}
+	}
+
+	if c.BasicAuth != nil {
+		password := &file{
+			path: c.BasicAuth.PasswordFile,
+		}
+		// Fail fast if the file can't be read
+		if _, err := password.Read(); err != nil {
+			return nil, err
+		}
+		rt = &basicAuth{
+			username: c.BasicAuth.Username,
+			password: password,
+			next:     rt,
+		}
+	}
+
+	if c.BearerTokenFile != "" {
+		token := &file{
+			path: c.BearerTokenFile,
+		}
+		// Fail fast if the file can't be read
+		if _, err := token.Read(); err != nil {
+			return nil, err
+		}
+		rt = &bearerToken{
+			token: token,
+			next:  rt,
+		}
+	}
+
+	return rt, nil
+}
+
+// headers is a type that represents an http.RoundTripper that adds headers to requests
+type headers struct {
+	headers config.Headers
+	next    http.RoundTripper
+}
+
+// RoundTrip is a method that implements http.RoundTripper
+func (rt *headers) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	rqst = rqst.Clone(rqst.Context())
+	for name, value := range rt.headers {
+		rqst.Header.Set(name, value)
+	}
+	return rt.next.RoundTrip(rqst)
+}
+
+// basicAuth is a type that represents an http.RoundTripper that authenticates requests using basic auth
+type basicAuth struct {
+	username string
+	password *file
+	next     http.RoundTripper
+}
+
+// RoundTrip is a method that implements http.RoundTripper
+func (rt *basicAuth) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	password, err := rt.password.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	rqst = rqst.Clone(rqst.Context())
+	rqst.SetBasicAuth(rt.username, password)
+	return rt.next.RoundTrip(rqst)
+}
+
+// bearerToken is a type that represents an http.RoundTripper that authenticates requests using a bearer token
+type bearerToken struct {
+	token *file
+	next  http.RoundTripper
+}
+
+// RoundTrip is a method that implements http.RoundTripper
+func (rt *bearerToken) RoundTrip(rqst *http.Request) (*http.Response, error) {
+	token, err := rt.token.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	rqst = rqst.Clone(rqst.Context())
+	rqst.Header.Set("Authorization", "Bearer "+token)
+	return rt.next.RoundTrip(rqst)
+}
+
+// file is a type that represents a file containing a secret
+// The file is reread when it changes so that rotated secrets (e.g. service account tokens) are used
+// An empty path represents an empty secret
+type file struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+// Read is a method that returns the file's (trimmed) content rereading the file if it has changed
+func (f *file) Read() (string, error) {
+	if f.path == "" {
+		return "", nil
+	}
+
+	// Stat follows symlinks so Kubernetes' (symlinked) secret updates are detected
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %q: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.modTime.IsZero() && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.value, nil
+	}
+
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %q: %w", f.path, err)
+	}
+
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	f.value = strings.TrimSpace(string(b))
+
+	return f.value, nil
+}
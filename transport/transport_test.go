@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DazWilkin/prometheus-mcp-server/config"
+)
+
+// newServer is a function that creates a server that records the headers of the last request
+func newServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+
+	got := &http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = r.Header.Clone()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, got
+}
+
+// get is a function that GETs the URL using the RoundTripper
+func get(t *testing.T, rt http.RoundTripper, url string) {
+	t.Helper()
+
+	client := &http.Client{
+		Transport: rt,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("unable to GET %q: %+v", url, err)
+	}
+	resp.Body.Close()
+}
+
+// write is a function that writes a file with a modification time
+func write(t *testing.T, path, s string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+		t.Fatalf("unable to write file: %+v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unable to change file times: %+v", err)
+	}
+}
+
+// TestHeaders tests that configured headers are added to requests
+func TestHeaders(t *testing.T) {
+	server, got := newServer(t)
+
+	rt, err := New(config.HTTPClient{
+		Headers: config.Headers{
+			"X-Team": "sre",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+
+	get(t, rt, server.URL)
+
+	if v := got.Get("X-Team"); v != "sre" {
+		t.Errorf("got: %q; want: %q", v, "sre")
+	}
+}
+
+// TestBasicAuth tests that requests are authenticated using basic auth
+func TestBasicAuth(t *testing.T) {
+	server, got := newServer(t)
+
+	path := filepath.Join(t.TempDir(), "password")
+	write(t, path, "secret\n", time.Now())
+
+	rt, err := New(config.HTTPClient{
+		BasicAuth: &config.BasicAuth{
+			Username:     "admin",
+			PasswordFile: path,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+
+	get(t, rt, server.URL)
+
+	rqst := &http.Request{Header: *got}
+	username, password, ok := rqst.BasicAuth()
+	if !ok || username != "admin" || password != "secret" {
+		t.Errorf("got: %q, %q, %t; want: %q, %q", username, password, ok, "admin", "secret")
+	}
+}
+
+// TestBearerToken tests that requests are authenticated using a bearer token that is reread when it changes
+func TestBearerToken(t *testing.T) {
+	server, got := newServer(t)
+
+	path := filepath.Join(t.TempDir(), "token")
+	now := time.Now()
+	write(t, path, "token1", now)
+
+	rt, err := New(config.HTTPClient{
+		BearerTokenFile: path,
+	})
+	if err != nil {
+		t.Fatalf("unable to create RoundTripper: %+v", err)
+	}
+
+	get(t, rt, server.URL)
+	if v := got.Get("Authorization"); v != "Bearer token1" {
+		t.Errorf("got: %q; want: %q", v, "Bearer token1")
+	}
+
+	// Rotate the token
+	write(t, path, "token2", now.Add(time.Minute))
+
+	get(t, rt, server.URL)
+	if v := got.Get("Authorization"); v != "Bearer token2" {
+		t.Errorf("got: %q; want: %q", v, "Bearer token2")
+	}
+}
+
+// TestMissingFile tests that New fails if a file can't be read
+func TestMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+	if _, err := New(config.HTTPClient{BearerTokenFile: path}); err == nil {
+		t.Errorf("expected error")
+	}
+}